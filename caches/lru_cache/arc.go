@@ -0,0 +1,131 @@
+package lru_cache
+
+// arcList identifies which of an arcPolicy's lists holds a key.
+type arcList int
+
+const (
+	arcT1 arcList = iota
+	arcT2
+	arcB1
+	arcB2
+)
+
+// arcPolicy implements ARC, per Megiddo & Modha. T1 holds cached keys seen once
+// and T2 cached keys seen more than once, both in LRU order. B1 and B2 are ghost
+// lists of keys recently evicted from T1 and T2, respectively. Ghost hits adapt
+// the target size of T1, p, toward whichever list would have kept the key.
+type arcPolicy[K comparable] struct {
+	t1, t2, b1, b2 *doublyLinkedList[K, arcList]
+	// Nodes hold their list as their item.
+	nodes    map[K]*node[K, arcList]
+	capacity int
+	p        int
+	// ghostHitB2 notes that the last Add hit B2, which biases the next Evict toward T1.
+	ghostHitB2 bool
+}
+
+func newARCPolicy[K comparable](capacity int) *arcPolicy[K] {
+	return &arcPolicy[K]{
+		t1:       newDoublyLinkedList[K, arcList](),
+		t2:       newDoublyLinkedList[K, arcList](),
+		b1:       newDoublyLinkedList[K, arcList](),
+		b2:       newDoublyLinkedList[K, arcList](),
+		nodes:    make(map[K]*node[K, arcList]),
+		capacity: capacity,
+	}
+}
+
+func (p *arcPolicy[K]) list(id arcList) *doublyLinkedList[K, arcList] {
+	switch id {
+	case arcT1:
+		return p.t1
+	case arcT2:
+		return p.t2
+	case arcB1:
+		return p.b1
+	}
+	return p.b2
+}
+
+// moveTo moves n to the front of the identified list.
+func (p *arcPolicy[K]) moveTo(n *node[K, arcList], id arcList) {
+	_ = p.list(n.item).Remove(n)
+	n.item = id
+	p.list(id).Prepend(n)
+}
+
+func (p *arcPolicy[K]) Add(key K) {
+	n, ok := p.nodes[key]
+	if !ok {
+		n = &node[K, arcList]{key: key, item: arcT1}
+		p.nodes[key] = n
+		p.t1.Prepend(n)
+		return
+	}
+
+	// A ghost hit: the key would have stayed cached had its list been larger.
+	switch n.item {
+	case arcB1:
+		p.p = min(p.capacity, p.p+max(p.b2.count/p.b1.count, 1))
+	case arcB2:
+		p.p = max(0, p.p-max(p.b1.count/p.b2.count, 1))
+		p.ghostHitB2 = true
+	}
+	p.moveTo(n, arcT2)
+}
+
+func (p *arcPolicy[K]) Access(key K) {
+	if n, ok := p.nodes[key]; ok && (n.item == arcT1 || n.item == arcT2) {
+		p.moveTo(n, arcT2)
+	}
+}
+
+func (p *arcPolicy[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok && (n.item == arcT1 || n.item == arcT2) {
+		_ = p.list(n.item).Remove(n)
+		delete(p.nodes, key)
+	}
+}
+
+// Resize bounds the target size of T1 to the new capacity; the ghost lists
+// are trimmed by the following Evict calls.
+func (p *arcPolicy[K]) Resize(capacity int) {
+	p.capacity = capacity
+	p.p = min(p.p, capacity)
+}
+
+func (p *arcPolicy[K]) compact() {
+	p.nodes = compactMap(p.nodes)
+}
+
+func (p *arcPolicy[K]) Evict() (key K, ok bool) {
+	fromT1 := p.t1.count > 0 &&
+		(p.t1.count > p.p || (p.ghostHitB2 && p.t1.count == p.p) || p.t2.count == 0)
+	p.ghostHitB2 = false
+
+	var victim *node[K, arcList]
+	if fromT1 {
+		victim = p.t1.tail
+		p.moveTo(victim, arcB1)
+	} else if victim = p.t2.tail; victim != nil {
+		p.moveTo(victim, arcB2)
+	} else {
+		return
+	}
+
+	// Bound the ghost lists: T1 and B1 to the capacity, and all lists to twice it.
+	for p.t1.count+p.b1.count > p.capacity && p.b1.count > 0 {
+		p.forget(p.b1.tail)
+	}
+	for p.t1.count+p.t2.count+p.b1.count+p.b2.count > 2*p.capacity && p.b2.count > 0 {
+		p.forget(p.b2.tail)
+	}
+
+	return victim.key, true
+}
+
+// forget removes a ghost entirely.
+func (p *arcPolicy[K]) forget(n *node[K, arcList]) {
+	_ = p.list(n.item).Remove(n)
+	delete(p.nodes, n.key)
+}
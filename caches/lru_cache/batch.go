@@ -0,0 +1,215 @@
+package lru_cache
+
+import "errors"
+
+// ErrBatchAborted is returned for the items of a batch that were not applied
+// because another item of the batch failed.
+var ErrBatchAborted error = errors.New("batch aborted by another item's error")
+
+// KeyValue is an item and its key, as passed to PutMany.
+type KeyValue[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// keyedOp is a save or delete of key, as applied to the store by a batch.
+type keyedOp[K comparable, V any] struct {
+	key K
+	op  writeOp[V]
+}
+
+// storedValue is the value of a key in the store before a batch, if it existed.
+type storedValue[V any] struct {
+	value  V
+	exists bool
+}
+
+// PutMany adds the passed items to the cache, in order, per Put, and then evicts
+// old items once. The batch is atomic: every item is checked before any is added,
+// and if one fails, e.g. because its key is already cached, or repeated in the
+// batch, or because it is too large, or cannot be saved to the store, then none
+// are added, nor left in the store. The returned errors are those of each item
+// in turn, which are all nil if the batch was applied, or else ErrBatchAborted
+// for those that did not themselves fail. As for a sequence of Puts, items may be
+// evicted by later items in the same batch if it exceeds the cache's capacity.
+func (cache *Cache[K, V]) PutMany(items []KeyValue[K, V]) []error {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	errs := make([]error, len(items))
+	costs := make([]int64, len(items))
+	seen := make(map[K]struct{}, len(items))
+	failed := false
+	for i, kv := range items {
+		_, repeated := seen[kv.Key]
+		seen[kv.Key] = struct{}{}
+		if existing, ok := cache.itemMap[kv.Key]; repeated || (ok && !cache.expired(existing)) {
+			cache.counters.duplicates.Add(1)
+			errs[i], failed = ErrDuplicateItem, true
+			continue
+		}
+		if costs[i], errs[i] = cache.costOf(kv.Value); errs[i] != nil {
+			failed = true
+		}
+	}
+	if failed {
+		return abortBatch(errs)
+	}
+
+	ops := make([]keyedOp[K, V], len(items))
+	for i, kv := range items {
+		ops[i] = keyedOp[K, V]{key: kv.Key, op: writeOp[V]{value: kv.Value}}
+	}
+	if _, i, err := cache.saveBatchToStore(ops); err != nil {
+		errs[i] = err
+		return abortBatch(errs)
+	}
+
+	for i, kv := range items {
+		cache.insert(kv.Key, kv.Value, cache.defaultTTL, costs[i])
+	}
+	cache.counters.puts.Add(uint64(len(items)))
+	cache.evictOverCapacity()
+
+	return errs
+}
+
+// GetMany finds the items stored under the passed keys per Get, and returns those
+// found. The cache is locked once to find every key, which are rotated to the
+// front of the cache in order, such that the last key is the most recently used.
+// In ReadThrough mode, missing items are then loaded from the store individually,
+// and the errors of those that could not be loaded, other than ErrItemNotFound,
+// are returned by key. Keys that were not found have neither an item nor an error.
+func (cache *Cache[K, V]) GetMany(keys []K) (map[K]V, map[K]error) {
+	found := make(map[K]V, len(keys))
+	errs := make(map[K]error)
+	var missing []K
+
+	cache.mu.Lock()
+	for _, key := range keys {
+		if item, ok := cache.lookup(key); ok {
+			found[key] = item
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	cache.unlock()
+
+	if cache.storeMode&ReadThrough != 0 {
+		for _, key := range missing {
+			item, ok, err := cache.tryLoadFromStore(key)
+			switch {
+			case err != nil:
+				errs[key] = err
+			case ok:
+				found[key] = item
+			}
+		}
+	}
+
+	return found, errs
+}
+
+// RemoveMany deletes the items stored under the passed keys per Remove, and
+// returns the error of each key in turn. Keys that are neither cached nor known
+// to be in the store are reported as ErrItemNotFound, which does not affect the
+// others. The batch is otherwise atomic: if the store fails to delete a key, no
+// items are removed, the store is restored, and the other keys' errors are
+// ErrBatchAborted.
+func (cache *Cache[K, V]) RemoveMany(keys []K) []error {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	errs := make([]error, len(keys))
+	ops := make([]keyedOp[K, V], len(keys))
+	for i, key := range keys {
+		ops[i] = keyedOp[K, V]{key: key, op: writeOp[V]{delete: true}}
+	}
+	deleted, i, err := cache.saveBatchToStore(ops)
+	if err != nil {
+		errs[i] = err
+		return abortBatch(errs)
+	}
+
+	for i, key := range keys {
+		if target, ok := cache.itemMap[key]; ok {
+			cache.removeNode(target, EvictedRemoved)
+		} else if deleted == nil || !deleted[i] {
+			errs[i] = ErrItemNotFound
+		}
+	}
+
+	return errs
+}
+
+// abortBatch returns errs, with ErrBatchAborted for each item that has no error.
+func abortBatch(errs []error) []error {
+	for i, err := range errs {
+		if err == nil {
+			errs[i] = ErrBatchAborted
+		}
+	}
+	return errs
+}
+
+// saveBatchToStore applies the ops to the store per the cache's StoreMode, all
+// or none. In WriteBehind mode they are queued together; otherwise they are
+// applied in turn, and if one fails, those applied before it are undone, and its
+// index and error returned along with any errors undoing the others. Deleted
+// reports the ops that deleted a key known to be in the store, and is nil if the
+// store was not written synchronously. The caller must hold the write lock.
+func (cache *Cache[K, V]) saveBatchToStore(ops []keyedOp[K, V]) (deleted []bool, failed int, err error) {
+	switch {
+	case cache.storeMode&WriteThrough != 0:
+	case cache.writeBehind != nil:
+		if cache.writeBehind.enqueueMany(ops) {
+			return nil, 0, nil
+		}
+	default:
+		return nil, 0, nil
+	}
+
+	// The previous value of each key is loaded first, so that it can be restored.
+	previous := make([]storedValue[V], 0, len(ops))
+	deleted = make([]bool, len(ops))
+	for i, o := range ops {
+		value, loadErr := cache.store.Load(o.key)
+		if loadErr != nil && !errors.Is(loadErr, ErrItemNotFound) {
+			err = loadErr
+		} else if o.op.delete {
+			deleted[i] = loadErr == nil
+			if err = cache.store.Delete(o.key); errors.Is(err, ErrItemNotFound) {
+				err = nil
+			}
+		} else {
+			err = cache.store.Save(o.key, o.op.value)
+		}
+		if err != nil {
+			if undoErr := cache.undoBatch(ops[:i], previous); undoErr != nil {
+				err = errors.Join(err, undoErr)
+			}
+			return nil, i, err
+		}
+		previous = append(previous, storedValue[V]{value: value, exists: loadErr == nil})
+	}
+
+	return deleted, 0, nil
+}
+
+// undoBatch restores the previous values of the keys of the applied ops to the
+// store, in reverse order, and returns any errors doing so.
+func (cache *Cache[K, V]) undoBatch(applied []keyedOp[K, V], previous []storedValue[V]) error {
+	var errs []error
+	for i := len(applied) - 1; i >= 0; i-- {
+		var err error
+		if previous[i].exists {
+			err = cache.store.Save(applied[i].key, previous[i].value)
+		} else if err = cache.store.Delete(applied[i].key); errors.Is(err, ErrItemNotFound) {
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
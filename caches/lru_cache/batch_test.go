@@ -0,0 +1,210 @@
+package lru_cache
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// badKeyStore is a MemoryStore that fails every operation on the key "bad".
+type badKeyStore struct {
+	*MemoryStore[string, int]
+}
+
+func (s *badKeyStore) Load(key string) (int, error) {
+	if key == "bad" {
+		return 0, errStoreDown
+	}
+	return s.MemoryStore.Load(key)
+}
+
+func (s *badKeyStore) Save(key string, value int) error {
+	if key == "bad" {
+		return errStoreDown
+	}
+	return s.MemoryStore.Save(key, value)
+}
+
+func (s *badKeyStore) Delete(key string) error {
+	if key == "bad" {
+		return errStoreDown
+	}
+	return s.MemoryStore.Delete(key)
+}
+
+func TestBatches(t *testing.T) {
+	Convey("Batch operation tests", t, func() {
+		cache, err := New[string, int](3, WithMaxCost(10), WithCost(func(item int) int64 { return int64(item) }))
+		So(err, ShouldBeNil)
+
+		var evicted []string
+		cache.OnEvict(func(key string, item int, reason EvictionReason) {
+			if reason == EvictedCapacity {
+				evicted = append(evicted, key)
+			}
+		})
+
+		Convey("Given a batch of valid items, PutMany adds them all", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+
+			errs := cache.PutMany([]KeyValue[string, int]{{"b", 2}, {"c", 3}})
+			So(errs, ShouldResemble, []error{nil, nil})
+			So(cache.Keys(), ShouldResemble, []string{"c", "b", "a"})
+			So(cache.Stats().Puts, ShouldEqual, 3)
+		})
+
+		Convey("Given a batch with invalid items, PutMany adds none of them", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+
+			errs := cache.PutMany([]KeyValue[string, int]{
+				{"a", 1}, {"b", 2}, {"c", 11}, {"d", 3}, {"b", 4},
+			})
+			So(errs, ShouldHaveLength, 5)
+			So(errs[0], ShouldEqual, ErrDuplicateItem)
+			So(errs[1], ShouldEqual, ErrBatchAborted)
+			So(errs[2], ShouldHaveSameTypeAs, &ItemTooLargeError{})
+			So(errs[3], ShouldEqual, ErrBatchAborted)
+			So(errs[4], ShouldEqual, ErrDuplicateItem)
+
+			So(cache.Keys(), ShouldResemble, []string{"a"})
+			So(cache.Stats().Puts, ShouldEqual, 1)
+		})
+
+		Convey("Given a batch larger than the cache, eviction runs once after it", func() {
+			errs := cache.PutMany([]KeyValue[string, int]{
+				{"a", 1}, {"b", 1}, {"c", 1}, {"d", 1}, {"e", 1},
+			})
+			for _, err := range errs {
+				So(err, ShouldBeNil)
+			}
+			So(evicted, ShouldResemble, []string{"a", "b"})
+			So(cache.Keys(), ShouldResemble, []string{"e", "d", "c"})
+		})
+
+		Convey("Given a batch of keys, GetMany returns those found, and promotes them in order", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("b", 2), ShouldBeNil)
+			So(cache.Put("c", 3), ShouldBeNil)
+
+			found, errs := cache.GetMany([]string{"b", "z", "a"})
+			So(found, ShouldResemble, map[string]int{"a": 1, "b": 2})
+			So(errs, ShouldBeEmpty)
+			So(cache.Keys(), ShouldResemble, []string{"a", "b", "c"})
+
+			stats := cache.Stats()
+			So(stats.Hits, ShouldEqual, 2)
+			So(stats.Misses, ShouldEqual, 1)
+		})
+
+		Convey("Given a batch of keys, RemoveMany returns each key's error", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("b", 2), ShouldBeNil)
+
+			errs := cache.RemoveMany([]string{"a", "z", "b", "a"})
+			So(errs, ShouldResemble, []error{nil, ErrItemNotFound, nil, ErrItemNotFound})
+			So(cache.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Given a ReadThrough store, GetMany loads missing items from it", func() {
+			store := NewMemoryStore[string, int]()
+			So(store.Save("s", 5), ShouldBeNil)
+			cache, err := New[string, int](3, WithStore[string, int](store, ReadThrough))
+			So(err, ShouldBeNil)
+			So(cache.Put("a", 1), ShouldBeNil)
+
+			found, errs := cache.GetMany([]string{"a", "s", "z"})
+			So(found, ShouldResemble, map[string]int{"a": 1, "s": 5})
+			So(errs, ShouldBeEmpty)
+			So(cache.Contains("s"), ShouldBeTrue)
+		})
+
+		Convey("Given a store that fails for a key, batches are applied to it all or none", func() {
+			store := &badKeyStore{MemoryStore: NewMemoryStore[string, int]()}
+			So(store.Save("x", 9), ShouldBeNil)
+			cache, err := New[string, int](10, WithStore[string, int](store, ReadThrough|WriteThrough))
+			So(err, ShouldBeNil)
+
+			// The saves of x and y are undone once bad fails.
+			errs := cache.PutMany([]KeyValue[string, int]{{"x", 1}, {"y", 2}, {"bad", 3}})
+			So(errs, ShouldResemble, []error{ErrBatchAborted, ErrBatchAborted, errStoreDown})
+			So(cache.Len(), ShouldEqual, 0)
+			val, err := store.Load("x")
+			So(err, ShouldBeNil)
+			So(val, ShouldEqual, 9)
+			_, err = store.Load("y")
+			So(err, ShouldEqual, ErrItemNotFound)
+
+			So(cache.PutMany([]KeyValue[string, int]{{"a", 1}, {"b", 2}}), ShouldResemble, []error{nil, nil})
+			errs = cache.RemoveMany([]string{"a", "bad", "b"})
+			So(errs, ShouldResemble, []error{ErrBatchAborted, errStoreDown, ErrBatchAborted})
+			So(cache.Keys(), ShouldResemble, []string{"b", "a"})
+			val, err = store.Load("a")
+			So(err, ShouldBeNil)
+			So(val, ShouldEqual, 1)
+
+			// Keys only in the store are removed from it, and missing keys reported.
+			So(cache.Remove("a"), ShouldBeNil)
+			So(cache.RemoveMany([]string{"x", "b", "z"}), ShouldResemble, []error{nil, nil, ErrItemNotFound})
+			_, err = store.Load("x")
+			So(err, ShouldEqual, ErrItemNotFound)
+
+			found, loadErrs := cache.GetMany([]string{"bad", "z"})
+			So(found, ShouldBeEmpty)
+			So(loadErrs, ShouldResemble, map[string]error{"bad": errStoreDown})
+		})
+
+		Convey("Given a WriteBehind store, a batch is queued together", func() {
+			store := NewMemoryStore[string, int]()
+			cache, err := New[string, int](10, WithStore[string, int](store, WriteBehind))
+			So(err, ShouldBeNil)
+
+			So(cache.PutMany([]KeyValue[string, int]{{"a", 1}, {"b", 2}}), ShouldResemble, []error{nil, nil})
+			So(cache.RemoveMany([]string{"a"}), ShouldResemble, []error{nil})
+			So(cache.Flush(), ShouldBeNil)
+			_, err = store.Load("a")
+			So(err, ShouldEqual, ErrItemNotFound)
+			val, err := store.Load("b")
+			So(err, ShouldBeNil)
+			So(val, ShouldEqual, 2)
+
+			// Once closed, batches are written to the store directly.
+			So(cache.Close(), ShouldBeNil)
+			So(cache.PutMany([]KeyValue[string, int]{{"c", 3}}), ShouldResemble, []error{nil})
+			val, err = store.Load("c")
+			So(err, ShouldBeNil)
+			So(val, ShouldEqual, 3)
+		})
+
+		Convey("Given concurrent batches, readers never see partial batches", func() {
+			cache, err := New[int, int](100)
+			So(err, ShouldBeNil)
+
+			keys := []int{1, 2, 3, 4}
+			batch := make([]KeyValue[int, int], len(keys))
+			for i, key := range keys {
+				batch[i] = KeyValue[int, int]{Key: key, Value: key}
+			}
+
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					cache.PutMany(batch)
+					cache.RemoveMany(keys)
+				}
+			}()
+
+			partial := 0
+			for i := 0; i < 200; i++ {
+				found, _ := cache.GetMany(keys)
+				if n := len(found); n != 0 && n != len(keys) {
+					partial++
+				}
+			}
+			wg.Wait()
+			So(partial, ShouldEqual, 0)
+		})
+	})
+}
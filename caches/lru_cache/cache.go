@@ -2,14 +2,16 @@
 // Do not use, use hashicorp or another implementation instead;
 // for instance see: https://github.com/golang/groupcache/blob/master/lru/lru.go
 // Caches comes in many different flavors and modifications.
-// TODO/FUTURE: implement a store-backed lru  into which a store
-// (postgres, minio, etc) could be injected.
+// A Store (postgres, minio, etc) may be injected via WithStore for
+// read-through, write-through or write-behind caching.
 
 package lru_cache
 
 import (
+	"context"
 	"errors"
 	"sync"
+	"time"
 )
 
 var (
@@ -19,6 +21,8 @@ var (
 	ErrDuplicateItem error = errors.New("duplicate item")
 	// ErrItemNotFound is returned when an object id is not in the cache.
 	ErrItemNotFound error = errors.New("item id not found")
+	// ErrInvalidOption is returned when an Option's value is invalid.
+	ErrInvalidOption error = errors.New("invalid cache option")
 )
 
 // CacheObject implements an ID() method for use as a map key.
@@ -27,115 +31,547 @@ type CacheObject interface {
 	ID() int
 }
 
-// Cache is a least-recently-used cache.
-type Cache struct {
-	// TODO: locking
-	itemMap  map[int]*node
-	itemList *doublyLinkedList
+// Cache is a least-recently-used cache of values keyed by any comparable type.
+// Other eviction policies may be selected via WithPolicy, in which case the
+// cache's list still tracks recency, but the policy chooses what is evicted.
+type Cache[K comparable, V any] struct {
+	itemMap  map[K]*node[K, V]
+	itemList *doublyLinkedList[K, V]
 	capacity int
 	mu       sync.RWMutex
+	// policy is nil for LRU, which evicts directly from itemList.
+	policy EvictionPolicy[K]
+	// If maxCost is positive, items are also evicted while their total cost
+	// exceeds it. Items cost one unless costFunc is set.
+	maxCost  int64
+	cost     int64
+	costFunc func(V) int64
+	// Expiration: entries are put with defaultTTL unless otherwise specified,
+	// and clock determines when they expire.
+	defaultTTL time.Duration
+	clock      Clock
+	// The optional janitor is stopped by closing stop, and closes done on exit.
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	// The optional backing store, and the queue of its pending writes in WriteBehind mode.
+	store       Store[K, V]
+	storeMode   StoreMode
+	writeBehind *writeBehindQueue[K, V]
+	// onEvict is called for each item in evicted once the write lock is released.
+	onEvict func(key K, item V, reason EvictionReason)
+	evicted []eviction[K, V]
+	// In-flight GetOrLoad calls, and recent loader errors, guarded by loadMu.
+	loadMu      sync.Mutex
+	flights     map[K]*flight[V]
+	negatives   map[K]negativeEntry
+	negativeTTL time.Duration
+	// counters are updated atomically and reported by Stats.
+	counters counters
+	// Items are refreshed by refresher once they are softTTL old, per WithRefresh.
+	// Refreshes are passed refreshCtx, which Close cancels before it waits for
+	// those in flight, which refreshes counts.
+	softTTL        time.Duration
+	refresher      func(ctx context.Context, key K) (V, error)
+	onRefreshError func(key K, err error)
+	refreshCtx     context.Context
+	cancelRefresh  context.CancelFunc
+	refreshes      sync.WaitGroup
+	// tagged indexes the keys of the items with each tag, and prefixes indexes
+	// the keys of string-keyed caches, and is nil for others.
+	tagged   map[string]map[K]struct{}
+	prefixes *prefixIndex
+	// Snapshots written by SaveTo are in snapshotFormat, with items marshaled by
+	// codec, or by default in the same format.
+	snapshotFormat SnapshotFormat
+	codec          Codec[V]
 }
 
-// NewCache initializes a cache of the passed capacity.
-func NewCache(capacity int) (*Cache, error) {
+// New initializes a cache of the passed capacity.
+func New[K comparable, V any](capacity int, opts ...Option) (*Cache[K, V], error) {
 	if capacity <= 0 {
 		return nil, ErrInvalidSize
 	}
 
-	return &Cache{
-		itemMap:  make(map[int]*node, capacity),
-		itemList: newDoublyLinkedList(),
-		capacity: capacity,
-		mu:       sync.RWMutex{},
-	}, nil
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	var costFunc func(V) int64
+	if o.costFunc != nil {
+		var ok bool
+		if costFunc, ok = o.costFunc.(func(V) int64); !ok {
+			return nil, ErrInvalidOption
+		}
+	}
+
+	var store Store[K, V]
+	if o.store != nil {
+		var ok bool
+		if store, ok = o.store.(Store[K, V]); !ok {
+			return nil, ErrInvalidOption
+		}
+	}
+
+	var refresher func(ctx context.Context, key K) (V, error)
+	if o.refresher != nil {
+		var ok bool
+		if refresher, ok = o.refresher.(func(ctx context.Context, key K) (V, error)); !ok {
+			return nil, ErrInvalidOption
+		}
+	}
+
+	var codec Codec[V]
+	if o.codec != nil {
+		var ok bool
+		if codec, ok = o.codec.(Codec[V]); !ok {
+			return nil, ErrInvalidOption
+		}
+	}
+
+	cache := &Cache[K, V]{
+		itemMap:        make(map[K]*node[K, V], capacity),
+		itemList:       newDoublyLinkedList[K, V](),
+		capacity:       capacity,
+		mu:             sync.RWMutex{},
+		defaultTTL:     o.defaultTTL,
+		clock:          o.clock,
+		store:          store,
+		storeMode:      o.storeMode,
+		policy:         newEvictionPolicy[K](o.policy, capacity),
+		maxCost:        o.maxCost,
+		costFunc:       costFunc,
+		flights:        make(map[K]*flight[V]),
+		negatives:      make(map[K]negativeEntry),
+		negativeTTL:    o.negativeTTL,
+		softTTL:        o.softTTL,
+		refresher:      refresher,
+		tagged:         make(map[string]map[K]struct{}),
+		prefixes:       newKeyIndex[K](),
+		snapshotFormat: o.snapshotFormat,
+		codec:          codec,
+	}
+
+	if o.storeMode&WriteBehind != 0 {
+		cache.writeBehind = newWriteBehindQueue(store)
+	}
+
+	if refresher != nil {
+		cache.refreshCtx, cache.cancelRefresh = context.WithCancel(context.Background())
+	}
+
+	if o.janitorInterval > 0 {
+		cache.startJanitor(o.janitorInterval)
+	}
+
+	return cache, nil
 }
 
-// Put adds the passed item to the cache and evicts old items.
-// Put returns an error if the insertion failed or the object already exists.
-func (cache *Cache) Put(item CacheObject) (err error) {
+// Put adds the passed item to the cache under key and evicts old items.
+// The item expires after the cache's default TTL, if one was set.
+// Put returns an error if the insertion failed or the key already exists.
+func (cache *Cache[K, V]) Put(key K, item V) error {
+	return cache.PutWithTTL(key, item, cache.defaultTTL)
+}
+
+// PutWithTTL adds the passed item to the cache under key and evicts old items.
+// The item expires after ttl; a non-positive ttl means it never expires.
+// PutWithTTL returns an error if the insertion failed or the key already exists,
+// or an *ItemTooLargeError if the item's cost exceeds the cache's MaxCost.
+// In WriteThrough mode the item is not cached if it could not be saved.
+func (cache *Cache[K, V]) PutWithTTL(key K, item V, ttl time.Duration) error {
 	cache.mu.Lock()
-	defer cache.mu.Unlock()
+	defer cache.unlock()
+
+	if err := cache.put(key, item, ttl); err != nil {
+		return err
+	}
+	cache.evictOverCapacity()
+
+	return nil
+}
+
+// put inserts the item per PutWithTTL, but does not evict old items.
+// The caller must hold the write lock.
+func (cache *Cache[K, V]) put(key K, item V, ttl time.Duration) error {
+	if existing, ok := cache.itemMap[key]; ok && !cache.expired(existing) {
+		cache.counters.duplicates.Add(1)
+		return ErrDuplicateItem
+	}
+
+	cost, err := cache.costOf(item)
+	if err != nil {
+		return err
+	}
 
-	if _, ok := cache.itemMap[item.ID()]; ok {
-		err = ErrDuplicateItem
+	if err := cache.saveToStore(key, item); err != nil {
+		return err
+	}
+
+	cache.insert(key, item, ttl, cost)
+	cache.counters.puts.Add(1)
+
+	return nil
+}
+
+// Set adds or replaces the item stored under key, moving it to the front of the
+// cache, and evicts old items. The item expires after the cache's default TTL,
+// if one was set. Replaced items are reported to OnEvict as EvictedReplaced.
+func (cache *Cache[K, V]) Set(key K, item V) error {
+	return cache.SetWithTTL(key, item, cache.defaultTTL)
+}
+
+// SetWithTTL adds or replaces the item stored under key per Set, except that
+// the item expires after ttl; a non-positive ttl means it never expires.
+// SetWithTTL returns an *ItemTooLargeError if the item's cost exceeds the
+// cache's MaxCost. In WriteThrough mode the item is not cached if it could not be saved.
+func (cache *Cache[K, V]) SetWithTTL(key K, item V, ttl time.Duration) (err error) {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	cost, err := cache.costOf(item)
+	if err != nil {
 		return
 	}
 
-	newNode := &node{
-		item: item,
+	if err = cache.saveToStore(key, item); err != nil {
+		return
+	}
+
+	if existing, ok := cache.itemMap[key]; ok && !cache.expired(existing) {
+		cache.replace(existing, item, ttl, cost)
+	} else {
+		cache.add(key, item, ttl, cost)
 	}
+	cache.counters.puts.Add(1)
+
+	return
+}
+
+// replace updates target in place with the item of the passed cost, which counts
+// as an access of it, and evicts old items. The caller must hold the write lock.
+func (cache *Cache[K, V]) replace(target *node[K, V], item V, ttl time.Duration, cost int64) {
+	cache.notifyEvicted(target, EvictedReplaced)
 
-	// TODO: error handling on insertion
-	// TODO: verify if indices are off by one (e.g. list evicts too many/few nodes)
+	target.item = item
+	target.generation++
+	cache.cost += cost - target.cost
+	target.cost = cost
+	cache.setExpiry(target, ttl)
+
+	cache.touch(target)
+	cache.evictOverCapacity()
+}
+
+// add inserts the item of the passed cost at the front of the cache, replacing
+// any existing item under key, and evicts old items. The caller must hold the
+// write lock.
+func (cache *Cache[K, V]) add(key K, item V, ttl time.Duration, cost int64) {
+	cache.insert(key, item, ttl, cost)
+	cache.evictOverCapacity()
+}
+
+// insert inserts the item per add, but does not evict old items.
+// The caller must hold the write lock.
+func (cache *Cache[K, V]) insert(key K, item V, ttl time.Duration, cost int64) {
+	if existing, ok := cache.itemMap[key]; ok {
+		reason := EvictedReplaced
+		if cache.expired(existing) {
+			reason = EvictedExpired
+		}
+		cache.removeNode(existing, reason)
+	}
+
+	newNode := &node[K, V]{
+		key:  key,
+		item: item,
+		cost: cost,
+	}
+	cache.setExpiry(newNode, ttl)
 
 	// Add the item to the front of the list
 	cache.itemList.Prepend(newNode)
 	// Store the item in hash table
-	cache.itemMap[item.ID()] = newNode
+	cache.itemMap[key] = newNode
+	cache.cost += cost
+	cache.indexKey(key)
 
-	// Evict least-recently-used nodes over capacity
-	evicted := cache.itemList.TrimRight(cache.capacity)
-	for evicted != nil {
-		// TODO: underlying map size is not reduced after deletion, a memory leak.
-		delete(cache.itemMap, evicted.item.ID())
-		evicted.prev = nil
-		evicted = evicted.next
+	if cache.policy != nil {
+		cache.policy.Add(key)
 	}
+}
 
-	return
+// addLoaded caches an item loaded from outside the cache, e.g. from its store, and
+// returns it. If another goroutine cached the key while the item was loaded, that
+// item takes precedence and is returned instead. Items too large to cache are
+// still returned.
+func (cache *Cache[K, V]) addLoaded(key K, item V) V {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	if existing, ok := cache.itemMap[key]; ok && !cache.expired(existing) {
+		cache.touch(existing)
+		return existing.item
+	}
+
+	if cost, err := cache.costOf(item); err == nil {
+		cache.add(key, item, cache.defaultTTL, cost)
+	}
+
+	return item
 }
 
-// Get finds the passed item and returns it if it exists.
-// If found, the item is rotated to the front of the cache.
-func (cache *Cache) Get(id int) (item CacheObject, exists bool) {
-	cache.mu.RLock()
-	defer cache.mu.RUnlock()
+// evictOverCapacity evicts items, as chosen by the policy, until the cache is
+// within its capacity and cost budget. The caller must hold the write lock.
+func (cache *Cache[K, V]) evictOverCapacity() {
+	for cache.overCapacity() {
+		victim := cache.victim()
+		if victim == nil {
+			return
+		}
+		cache.unlinkNode(victim, EvictedCapacity)
+	}
+}
+
+// overCapacity returns true if the cache holds too many items, or items of too great a cost.
+func (cache *Cache[K, V]) overCapacity() bool {
+	return cache.itemList.count > cache.capacity ||
+		(cache.maxCost > 0 && cache.cost > cache.maxCost)
+}
+
+// victim returns the next node to evict per the policy, or nil if there is none.
+func (cache *Cache[K, V]) victim() *node[K, V] {
+	if cache.policy == nil {
+		// The least-recently-used node
+		return cache.itemList.tail
+	}
+
+	for {
+		key, ok := cache.policy.Evict()
+		if !ok {
+			return nil
+		}
+		if target, ok := cache.itemMap[key]; ok {
+			return target
+		}
+	}
+}
+
+// Get finds the item stored under key and returns it if it exists.
+// If found, the item is rotated to the front of the cache. Expired
+// items are removed and reported as missing. In ReadThrough mode,
+// missing items are loaded from the store and cached.
+func (cache *Cache[K, V]) Get(key K) (item V, exists bool) {
+	if item, exists = cache.get(key); exists || cache.storeMode&ReadThrough == 0 {
+		return
+	}
 
-	var target *node
-	target, exists = cache.itemMap[id]
+	return cache.loadFromStore(key)
+}
+
+func (cache *Cache[K, V]) get(key K) (item V, exists bool) {
+	// Get rotates the list and removes expired items, so it requires the write lock.
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	return cache.lookup(key)
+}
+
+// lookup finds the item stored under key per Get, but does not consult the
+// store. The caller must hold the write lock.
+func (cache *Cache[K, V]) lookup(key K) (item V, exists bool) {
+	var target *node[K, V]
+	target, exists = cache.itemMap[key]
 	if !exists {
+		cache.counters.misses.Add(1)
 		return
 	}
 
-	// Rotate item to front of list
-	_ = cache.itemList.RotateFront(target)
+	if cache.expired(target) {
+		cache.removeNode(target, EvictedExpired)
+		cache.counters.misses.Add(1)
+		exists = false
+		return
+	}
+
+	cache.counters.hits.Add(1)
+	cache.touch(target)
+	if cache.stale(target) {
+		cache.startRefresh(target)
+	}
 	item = target.item
 
 	return
 }
 
-func (cache *Cache) Remove(id int) error {
+// Peek returns the item stored under key, if it exists and has not expired,
+// without moving it to the front of the cache.
+func (cache *Cache[K, V]) Peek(key K) (item V, exists bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	target, exists := cache.itemMap[key]
+	if !exists || cache.expired(target) {
+		return item, false
+	}
+
+	return target.item, true
+}
+
+// Contains returns true if an unexpired item is stored under key, without
+// moving it to the front of the cache.
+func (cache *Cache[K, V]) Contains(key K) bool {
+	_, exists := cache.Peek(key)
+	return exists
+}
+
+// Remove deletes the item stored under key, or returns ErrItemNotFound.
+// In WriteThrough and WriteBehind modes the item is also deleted from the store.
+// In WriteThrough mode ErrItemNotFound is only returned if neither the cache
+// nor the store held the item.
+func (cache *Cache[K, V]) Remove(key K) error {
 	cache.mu.Lock()
-	defer cache.mu.Unlock()
+	defer cache.unlock()
+
+	return cache.remove(key)
+}
+
+// remove deletes the item stored under key per Remove.
+// The caller must hold the write lock.
+func (cache *Cache[K, V]) remove(key K) error {
+	target, ok := cache.itemMap[key]
+
+	deleted, err := cache.deleteFromStore(key)
+	if err != nil {
+		return err
+	}
 
-	target, ok := cache.itemMap[id]
 	if !ok {
+		if deleted {
+			return nil
+		}
 		return ErrItemNotFound
 	}
 
-	if err := cache.itemList.Remove(target); err != nil {
-		return err
+	cache.removeNode(target, EvictedRemoved)
+
+	return nil
+}
+
+// Close stops the cache's background janitor, if any, and waits for it to exit.
+// It likewise cancels the context of any refreshes in flight per WithRefresh,
+// discards their results, and waits for them to return. In WriteBehind mode, Close also flushes pending writes to the store and
+// returns the first error encountered writing them.
+// Close is safe to call more than once.
+func (cache *Cache[K, V]) Close() (err error) {
+	cache.closeOnce.Do(func() {
+		if cache.stop != nil {
+			close(cache.stop)
+			<-cache.done
+		}
+		if cache.cancelRefresh != nil {
+			// Refreshes are started under the write lock, and not once it is
+			// cancelled, so that none are started after the wait.
+			cache.mu.Lock()
+			cache.cancelRefresh()
+			cache.mu.Unlock()
+			cache.refreshes.Wait()
+		}
+		if cache.writeBehind != nil {
+			err = cache.writeBehind.close()
+		}
+	})
+	return
+}
+
+// touch records a hit on target, rotating it to the front of the list.
+// The caller must hold the write lock.
+func (cache *Cache[K, V]) touch(target *node[K, V]) {
+	_ = cache.itemList.RotateFront(target)
+	if cache.policy != nil {
+		cache.policy.Access(target.key)
 	}
+}
 
-	delete(cache.itemMap, target.item.ID())
+// removeNode removes target from the cache and its policy, for the passed reason.
+// The caller must hold the write lock.
+func (cache *Cache[K, V]) removeNode(target *node[K, V], reason EvictionReason) {
+	if cache.policy != nil {
+		cache.policy.Remove(target.key)
+	}
+	cache.unlinkNode(target, reason)
+}
 
-	return nil
+// unlinkNode unlinks target from both the list and the map, for the passed reason.
+// The caller must hold the write lock.
+func (cache *Cache[K, V]) unlinkNode(target *node[K, V], reason EvictionReason) {
+	// err intentionally discarded since target is known to be non-nil
+	_ = cache.itemList.Remove(target)
+	// Go maps do not release memory as keys are deleted; Resize rebuilds the map
+	// after large shrinks, and otherwise its size is bounded by the capacity.
+	delete(cache.itemMap, target.key)
+	cache.cost -= target.cost
+	cache.unindex(target)
+	cache.notifyEvicted(target, reason)
+}
+
+// ObjectCache adapts a Cache to the original CacheObject api, for which
+// items are keyed by their ID(). All other Cache methods are promoted.
+type ObjectCache struct {
+	*Cache[int, CacheObject]
+}
+
+// NewCache initializes an ObjectCache of the passed capacity.
+func NewCache(capacity int, opts ...Option) (*ObjectCache, error) {
+	cache, err := New[int, CacheObject](capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectCache{Cache: cache}, nil
+}
+
+// Put adds the passed item to the cache and evicts old items.
+// Put returns an error if the insertion failed or the object already exists.
+func (cache *ObjectCache) Put(item CacheObject) error {
+	return cache.Cache.Put(item.ID(), item)
+}
+
+// Set adds or replaces the passed item in the cache, per Cache.Set.
+func (cache *ObjectCache) Set(item CacheObject) error {
+	return cache.Cache.Set(item.ID(), item)
 }
 
-type node struct {
-	next *node
-	prev *node
-	item CacheObject
+type node[K comparable, V any] struct {
+	next *node[K, V]
+	prev *node[K, V]
+	key  K
+	item V
+	// expires is the zero time if the item never expires, and stale the zero
+	// time if it is never refreshed, per WithRefresh.
+	expires    time.Time
+	stale      time.Time
+	refreshing bool
+	// generation is incremented whenever the item is replaced in place, so that
+	// a refresh started before the replacement can tell that its result is stale.
+	generation uint64
+	cost       int64
+	// tags are those passed to PutWithTags.
+	tags []string
 }
 
-type doublyLinkedList struct {
-	head  *node
-	tail  *node
+type doublyLinkedList[K comparable, V any] struct {
+	head  *node[K, V]
+	tail  *node[K, V]
 	count int
 }
 
-func newDoublyLinkedList() *doublyLinkedList {
-	return &doublyLinkedList{
+func newDoublyLinkedList[K comparable, V any]() *doublyLinkedList[K, V] {
+	return &doublyLinkedList[K, V]{
 		head:  nil,
 		tail:  nil,
 		count: 0,
@@ -144,7 +580,7 @@ func newDoublyLinkedList() *doublyLinkedList {
 
 // Prepend inserts the passed node to the front of the list
 // and evicts any items over capacity.
-func (list *doublyLinkedList) Prepend(newNode *node) {
+func (list *doublyLinkedList[K, V]) Prepend(newNode *node[K, V]) {
 	// List is empty
 	if list.head == nil {
 		list.head = newNode
@@ -160,7 +596,7 @@ func (list *doublyLinkedList) Prepend(newNode *node) {
 	list.count++
 }
 
-func (list *doublyLinkedList) RotateFront(target *node) (err error) {
+func (list *doublyLinkedList[K, V]) RotateFront(target *node[K, V]) (err error) {
 	if target == nil {
 		return errItemNil
 	}
@@ -182,7 +618,7 @@ func (list *doublyLinkedList) RotateFront(target *node) (err error) {
 }
 
 // Slice the list at the zero-based nth position and return the first node from that position.
-func (list *doublyLinkedList) TrimRight(n int) (evicted *node) {
+func (list *doublyLinkedList[K, V]) TrimRight(n int) (evicted *node[K, V]) {
 	// Not at capacity, so just return.
 	if list.count <= n {
 		return
@@ -226,7 +662,7 @@ var errItemNil error = errors.New("node cannot be nil")
 // Remove removes the passed list node from the list and returns an
 // error if target is nil, otherwise returns nil on success.
 // If successful, no longer use the passed node to allow it to be removed.
-func (list *doublyLinkedList) Remove(target *node) (err error) {
+func (list *doublyLinkedList[K, V]) Remove(target *node[K, V]) (err error) {
 	if target == nil {
 		return errItemNil
 	}
@@ -249,11 +685,13 @@ func (list *doublyLinkedList) Remove(target *node) (err error) {
 	// Target is the first item in a list with successors.
 	if target.prev == nil {
 		list.head = target.next
+		list.head.prev = nil
 		return
 	}
 	// Target is the last item in a list with predecessors.
 	if target.next == nil {
 		list.tail = target.prev
+		list.tail.next = nil
 		return
 	}
 	// Target is in the middle of a list with predecessors and successors.
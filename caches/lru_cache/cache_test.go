@@ -2,6 +2,7 @@ package lru_cache
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -27,8 +28,8 @@ Full interview strategy:
 func TestList(t *testing.T) {
 	Convey("List tests", t, func() {
 		Convey("TrimRight tests", func() {
-			l := newDoublyLinkedList()
-			nodes := []*node{
+			l := newDoublyLinkedList[int, CacheObject]()
+			nodes := []*node[int, CacheObject]{
 				{item: &foo{id: 1}},
 				{item: &foo{id: 2}},
 				{item: &foo{id: 3}},
@@ -57,10 +58,10 @@ func TestList(t *testing.T) {
 		})
 
 		Convey("RotateFront tests", func() {
-			l := newDoublyLinkedList()
+			l := newDoublyLinkedList[int, CacheObject]()
 
 			Convey("When list is [1,2,3] and RotateFront is called on the last item", func() {
-				nodes := []*node{
+				nodes := []*node[int, CacheObject]{
 					{item: &foo{id: 1}},
 					{item: &foo{id: 2}},
 					{item: &foo{id: 3}},
@@ -82,7 +83,7 @@ func TestList(t *testing.T) {
 			})
 
 			Convey("When only one item is in the list and RotateFront is called", func() {
-				item := &node{item: &foo{id: 1}}
+				item := &node[int, CacheObject]{item: &foo{id: 1}}
 				l.Prepend(item)
 				err := l.RotateFront(item)
 				So(err, ShouldBeNil)
@@ -93,17 +94,17 @@ func TestList(t *testing.T) {
 		})
 
 		Convey("Initialization tests", func() {
-			l := newDoublyLinkedList()
+			l := newDoublyLinkedList[int, CacheObject]()
 			So(l.count, ShouldEqual, 0)
 			So(l.head, ShouldBeNil)
 			So(l.tail, ShouldBeNil)
 		})
 
 		Convey("Removal tests", func() {
-			l := newDoublyLinkedList()
+			l := newDoublyLinkedList[int, CacheObject]()
 			So(l.count, ShouldEqual, 0)
 
-			nodes := []*node{
+			nodes := []*node[int, CacheObject]{
 				{item: &foo{id: 1}},
 				{item: &foo{id: 2}},
 				{item: &foo{id: 3}},
@@ -154,10 +155,10 @@ func TestList(t *testing.T) {
 		})
 
 		Convey("Prepend tests", func() {
-			l := newDoublyLinkedList()
+			l := newDoublyLinkedList[int, CacheObject]()
 			So(l.count, ShouldEqual, 0)
 
-			nodes := []*node{
+			nodes := []*node[int, CacheObject]{
 				{item: &foo{id: 1}},
 				{item: &foo{id: 2}},
 				{item: &foo{id: 3}},
@@ -348,3 +349,126 @@ func TestCacheAdd(t *testing.T) {
 		})
 	})
 }
+
+func TestGenericCache(t *testing.T) {
+	Convey("Generic cache tests", t, func() {
+		Convey("Given a string-keyed cache, Put, Get and Remove succeed", func() {
+			cache, err := New[string, int](2)
+			So(err, ShouldBeNil)
+
+			err = cache.Put("abc", 1)
+			So(err, ShouldBeNil)
+			err = cache.Put("abc", 2)
+			So(err, ShouldEqual, ErrDuplicateItem)
+
+			val, ok := cache.Get("abc")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+
+			err = cache.Remove("abc")
+			So(err, ShouldBeNil)
+			_, ok = cache.Get("abc")
+			So(ok, ShouldBeFalse)
+			err = cache.Remove("abc")
+			So(err, ShouldEqual, ErrItemNotFound)
+		})
+
+		Convey("Given a struct-keyed cache at capacity, Put evicts the least-recently-used item", func() {
+			type point struct{ x, y int }
+			cache, err := New[point, string](2)
+			So(err, ShouldBeNil)
+
+			So(cache.Put(point{0, 0}, "origin"), ShouldBeNil)
+			So(cache.Put(point{1, 0}, "east"), ShouldBeNil)
+			// Touch the origin so that east becomes least-recently-used.
+			_, ok := cache.Get(point{0, 0})
+			So(ok, ShouldBeTrue)
+			So(cache.Put(point{0, 1}, "north"), ShouldBeNil)
+
+			_, ok = cache.Get(point{1, 0})
+			So(ok, ShouldBeFalse)
+			val, ok := cache.Get(point{0, 0})
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, "origin")
+			val, ok = cache.Get(point{0, 1})
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, "north")
+		})
+
+		Convey("Given an invalid capacity, New fails", func() {
+			_, err := New[string, string](-1)
+			So(err, ShouldBeError, ErrInvalidSize)
+		})
+	})
+}
+
+func TestCacheSet(t *testing.T) {
+	Convey("Set, Peek and Contains tests", t, func() {
+		cache, err := New[string, int](2)
+		So(err, ShouldBeNil)
+
+		var replaced []int
+		cache.OnEvict(func(key string, item int, reason EvictionReason) {
+			if reason == EvictedReplaced {
+				replaced = append(replaced, item)
+			}
+		})
+
+		Convey("Given an existing item, Set replaces it and moves it to the front", func() {
+			So(cache.Set("a", 1), ShouldBeNil)
+			So(cache.Set("b", 2), ShouldBeNil)
+			So(cache.Set("a", 3), ShouldBeNil)
+			So(replaced, ShouldResemble, []int{1})
+			So(cache.itemList.head.key, ShouldEqual, "a")
+
+			// b is now least-recently-used.
+			So(cache.Set("c", 4), ShouldBeNil)
+			So(cache.Contains("b"), ShouldBeFalse)
+			val, ok := cache.Get("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 3)
+			So(cache.Stats().Puts, ShouldEqual, 4)
+		})
+
+		Convey("Given an item, Peek and Contains find it without moving it to the front", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("b", 2), ShouldBeNil)
+
+			val, ok := cache.Peek("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+			So(cache.Contains("a"), ShouldBeTrue)
+			So(cache.itemList.head.key, ShouldEqual, "b")
+
+			_, ok = cache.Peek("c")
+			So(ok, ShouldBeFalse)
+			So(cache.Contains("c"), ShouldBeFalse)
+			So(cache.Stats().Hits, ShouldEqual, 0)
+		})
+
+		Convey("Given an expired item, Peek and Contains do not find it, and Set replaces it", func() {
+			clock := newFakeClock()
+			cache, err := New[string, int](2, WithClock(clock))
+			So(err, ShouldBeNil)
+			So(cache.SetWithTTL("a", 1, time.Second), ShouldBeNil)
+			clock.Advance(time.Second)
+
+			So(cache.Contains("a"), ShouldBeFalse)
+			_, ok := cache.Peek("a")
+			So(ok, ShouldBeFalse)
+
+			So(cache.Set("a", 2), ShouldBeNil)
+			val, ok := cache.Peek("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 2)
+		})
+
+		Convey("Given an ObjectCache, Set replaces objects by ID", func() {
+			cache, err := NewCache(2)
+			So(err, ShouldBeNil)
+			So(cache.Set(&foo{id: 1}), ShouldBeNil)
+			So(cache.Set(&foo{id: 1}), ShouldBeNil)
+			So(cache.Len(), ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,300 @@
+package lru_cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClockCache approximates a least-recently-used cache with the CLOCK algorithm.
+// Its items are kept in a ring of slots, each with a reference bit that Get sets
+// atomically while holding only the read lock, so that reads never contend for
+// the write lock or move items. When the cache is full, Put sweeps the ring's
+// hand past referenced items, clearing their bits, and evicts the first item it
+// finds unreferenced, or expired. Items therefore survive eviction for as long as
+// they are read at least once per sweep.
+//
+// ClockCache supports the WithDefaultTTL and WithClock options; NewClock returns
+// ErrInvalidOption for any other.
+type ClockCache[K comparable, V any] struct {
+	mu sync.RWMutex
+	// slots is the ring, which grows up to capacity, and index maps keys to
+	// their slots. Removed items leave their slots free for reuse.
+	slots    []clockSlot[K, V]
+	index    map[K]int
+	free     []int
+	hand     int
+	capacity int
+
+	defaultTTL time.Duration
+	clock      Clock
+	// onEvict is called for each item in evicted once the write lock is released.
+	onEvict func(key K, item V, reason EvictionReason)
+	evicted []eviction[K, V]
+}
+
+// clockSlot holds an item in the ring. All but referenced are guarded by the
+// cache's lock, which readers set while holding only the read lock.
+type clockSlot[K comparable, V any] struct {
+	key        K
+	item       V
+	expires    time.Time
+	referenced atomic.Bool
+}
+
+// NewClock initializes a CLOCK cache of the passed capacity.
+func NewClock[K comparable, V any](capacity int, opts ...Option) (*ClockCache[K, V], error) {
+	if capacity <= 0 {
+		return nil, ErrInvalidSize
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	unsupported := o.janitorInterval != 0 || o.store != nil || o.policy != LRU || o.maxCost != 0 ||
+		o.costFunc != nil || o.negativeTTL != 0 || o.codec != nil || o.snapshotFormat != GobFormat ||
+		o.softTTL != 0
+	if unsupported {
+		return nil, ErrInvalidOption
+	}
+
+	return &ClockCache[K, V]{
+		slots:      make([]clockSlot[K, V], 0, capacity),
+		index:      make(map[K]int, capacity),
+		capacity:   capacity,
+		defaultTTL: o.defaultTTL,
+		clock:      o.clock,
+	}, nil
+}
+
+// Put adds the item under key, evicting an item if the cache is full. The item
+// expires after the cache's default TTL, if one was set. Put returns
+// ErrDuplicateItem if an unexpired item is already stored under key.
+func (cache *ClockCache[K, V]) Put(key K, item V) error {
+	return cache.PutWithTTL(key, item, cache.defaultTTL)
+}
+
+// PutWithTTL adds the item per Put, except that it expires after ttl;
+// a non-positive ttl means it never expires.
+func (cache *ClockCache[K, V]) PutWithTTL(key K, item V, ttl time.Duration) error {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	if i, ok := cache.index[key]; ok {
+		if !cache.expired(&cache.slots[i]) {
+			return ErrDuplicateItem
+		}
+		cache.replace(i, item, ttl, EvictedExpired)
+		return nil
+	}
+
+	cache.insert(key, item, ttl)
+	return nil
+}
+
+// Set adds or replaces the item stored under key per Put. Replaced items are
+// reported to OnEvict as EvictedReplaced, and count as referenced.
+func (cache *ClockCache[K, V]) Set(key K, item V) error {
+	return cache.SetWithTTL(key, item, cache.defaultTTL)
+}
+
+// SetWithTTL adds or replaces the item per Set, except that it expires after ttl;
+// a non-positive ttl means it never expires.
+func (cache *ClockCache[K, V]) SetWithTTL(key K, item V, ttl time.Duration) error {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	i, ok := cache.index[key]
+	switch {
+	case !ok:
+		cache.insert(key, item, ttl)
+	case cache.expired(&cache.slots[i]):
+		cache.replace(i, item, ttl, EvictedExpired)
+	default:
+		cache.replace(i, item, ttl, EvictedReplaced)
+		cache.slots[i].referenced.Store(true)
+	}
+	return nil
+}
+
+// insert stores the item in a free slot, or else in that of an evicted item.
+// The caller must hold the write lock.
+func (cache *ClockCache[K, V]) insert(key K, item V, ttl time.Duration) {
+	var i int
+	switch {
+	case len(cache.free) > 0:
+		i = cache.free[len(cache.free)-1]
+		cache.free = cache.free[:len(cache.free)-1]
+	case len(cache.slots) < cache.capacity:
+		cache.slots = append(cache.slots, clockSlot[K, V]{})
+		i = len(cache.slots) - 1
+	default:
+		i = cache.sweep()
+	}
+
+	slot := &cache.slots[i]
+	slot.key = key
+	slot.item = item
+	slot.referenced.Store(false)
+	cache.setExpiry(slot, ttl)
+	cache.index[key] = i
+}
+
+// replace replaces the item in slot i, reporting the previous one for reason.
+// The caller must hold the write lock.
+func (cache *ClockCache[K, V]) replace(i int, item V, ttl time.Duration, reason EvictionReason) {
+	slot := &cache.slots[i]
+	cache.notifyEvicted(slot, reason)
+	slot.item = item
+	cache.setExpiry(slot, ttl)
+}
+
+// sweep advances the hand to the first expired or unreferenced item, clearing
+// the reference bits of those it passes, and evicts it. It returns the item's
+// slot, which is left for the caller to fill. The caller must hold the write
+// lock, and the ring must be full.
+func (cache *ClockCache[K, V]) sweep() int {
+	for {
+		i := cache.hand
+		cache.hand = (cache.hand + 1) % len(cache.slots)
+
+		slot := &cache.slots[i]
+		switch {
+		case cache.expired(slot):
+			cache.evict(i, EvictedExpired)
+		case slot.referenced.Load():
+			slot.referenced.Store(false)
+			continue
+		default:
+			cache.evict(i, EvictedCapacity)
+		}
+		return i
+	}
+}
+
+// evict removes the item in slot i from the index, and reports it for reason.
+// The slot itself is neither cleared nor freed. The caller must hold the write lock.
+func (cache *ClockCache[K, V]) evict(i int, reason EvictionReason) {
+	slot := &cache.slots[i]
+	delete(cache.index, slot.key)
+	cache.notifyEvicted(slot, reason)
+}
+
+// Get returns the item stored under key, if it exists and has not expired, and
+// marks it as referenced. Get only takes the read lock.
+func (cache *ClockCache[K, V]) Get(key K) (item V, exists bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	i, ok := cache.index[key]
+	if !ok {
+		return item, false
+	}
+	slot := &cache.slots[i]
+	if cache.expired(slot) {
+		return item, false
+	}
+
+	// Checking the bit first avoids writing to the slot's cache line when it is
+	// already set, as it is for frequently read items.
+	if !slot.referenced.Load() {
+		slot.referenced.Store(true)
+	}
+	return slot.item, true
+}
+
+// Peek returns the item stored under key, if it exists and has not expired,
+// without marking it as referenced.
+func (cache *ClockCache[K, V]) Peek(key K) (item V, exists bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	i, ok := cache.index[key]
+	if !ok || cache.expired(&cache.slots[i]) {
+		return item, false
+	}
+	return cache.slots[i].item, true
+}
+
+// Contains returns true if an unexpired item is stored under key, without
+// marking it as referenced.
+func (cache *ClockCache[K, V]) Contains(key K) bool {
+	_, exists := cache.Peek(key)
+	return exists
+}
+
+// Remove deletes the item stored under key, or returns ErrItemNotFound.
+func (cache *ClockCache[K, V]) Remove(key K) error {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	i, ok := cache.index[key]
+	if !ok {
+		return ErrItemNotFound
+	}
+
+	cache.evict(i, EvictedRemoved)
+	cache.slots[i] = clockSlot[K, V]{}
+	cache.free = append(cache.free, i)
+	return nil
+}
+
+// Len returns the number of cached items, including any that have expired
+// but are not yet removed.
+func (cache *ClockCache[K, V]) Len() int {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	return len(cache.index)
+}
+
+// OnEvict registers fn to be called for every item that leaves the cache, per
+// Cache.OnEvict. Expired items are only reported once they are swept or replaced.
+func (cache *ClockCache[K, V]) OnEvict(fn func(key K, item V, reason EvictionReason)) {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	cache.onEvict = fn
+}
+
+// expired returns true if slot has an expiration time that has passed.
+func (cache *ClockCache[K, V]) expired(slot *clockSlot[K, V]) bool {
+	return !slot.expires.IsZero() && !cache.clock.Now().Before(slot.expires)
+}
+
+// setExpiry sets slot to expire after ttl, if it is positive.
+func (cache *ClockCache[K, V]) setExpiry(slot *clockSlot[K, V], ttl time.Duration) {
+	slot.expires = time.Time{}
+	if ttl > 0 {
+		slot.expires = cache.clock.Now().Add(ttl)
+	}
+}
+
+// notifyEvicted records slot's item for the callback if one is registered.
+// The caller must hold the write lock.
+func (cache *ClockCache[K, V]) notifyEvicted(slot *clockSlot[K, V], reason EvictionReason) {
+	if cache.onEvict == nil {
+		return
+	}
+
+	cache.evicted = append(cache.evicted, eviction[K, V]{
+		key:    slot.key,
+		item:   slot.item,
+		reason: reason,
+	})
+}
+
+// unlock releases the write lock and then makes any pending eviction callbacks.
+func (cache *ClockCache[K, V]) unlock() {
+	evicted, onEvict := cache.evicted, cache.onEvict
+	cache.evicted = nil
+	cache.mu.Unlock()
+
+	for _, e := range evicted {
+		onEvict(e.key, e.item, e.reason)
+	}
+}
@@ -0,0 +1,68 @@
+package lru_cache
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// The benchmarks compare ClockCache to the linked-list Cache with a fixed number
+// of goroutines, regardless of -cpu, e.g. 'go test -bench Goroutines'.
+var benchGoroutines = []int{1, 8, 64}
+
+// benchmarkGoroutines runs the read-heavy mix of benchmarkParallel, split
+// across the passed number of goroutines.
+func benchmarkGoroutines(b *testing.B, cache benchCache, goroutines int) {
+	for i := 0; i < benchCapacity; i++ {
+		_ = cache.Put(i, i)
+	}
+
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		n := b.N / goroutines
+		if g < b.N%goroutines {
+			n++
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for i := 0; i < n; i++ {
+				key := r.Intn(benchKeys)
+				if i%10 == 0 {
+					_ = cache.Put(key, i)
+				} else {
+					_, _ = cache.Get(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkCacheGoroutines(b *testing.B) {
+	for _, goroutines := range benchGoroutines {
+		b.Run(fmt.Sprintf("goroutines=%d", goroutines), func(b *testing.B) {
+			cache, err := New[int, int](benchCapacity)
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkGoroutines(b, cache, goroutines)
+		})
+	}
+}
+
+func BenchmarkClockCacheGoroutines(b *testing.B) {
+	for _, goroutines := range benchGoroutines {
+		b.Run(fmt.Sprintf("goroutines=%d", goroutines), func(b *testing.B) {
+			cache, err := NewClock[int, int](benchCapacity)
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkGoroutines(b, cache, goroutines)
+		})
+	}
+}
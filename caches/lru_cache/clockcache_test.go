@@ -0,0 +1,149 @@
+package lru_cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClockCache(t *testing.T) {
+	Convey("ClockCache tests", t, func() {
+		Convey("Given an invalid size or option, it is rejected", func() {
+			_, err := NewClock[int, int](0)
+			So(err, ShouldEqual, ErrInvalidSize)
+			_, err = NewClock[int, int](1, WithPolicy(LFU))
+			So(err, ShouldEqual, ErrInvalidOption)
+			_, err = NewClock[int, int](1, WithMaxCost(10))
+			So(err, ShouldEqual, ErrInvalidOption)
+		})
+
+		Convey("Given puts and gets, items are stored", func() {
+			cache, err := NewClock[string, int](2)
+			So(err, ShouldBeNil)
+
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("a", 2), ShouldEqual, ErrDuplicateItem)
+			So(cache.Set("a", 3), ShouldBeNil)
+
+			val, ok := cache.Get("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 3)
+			_, ok = cache.Get("b")
+			So(ok, ShouldBeFalse)
+			So(cache.Len(), ShouldEqual, 1)
+		})
+
+		Convey("Given a full cache, referenced items get a second chance", func() {
+			cache, err := NewClock[int, int](3)
+			So(err, ShouldBeNil)
+			var evicted []int
+			cache.OnEvict(func(key, item int, reason EvictionReason) {
+				if reason == EvictedCapacity {
+					evicted = append(evicted, key)
+				}
+			})
+
+			for i := 0; i < 3; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+			_, _ = cache.Get(0)
+			_, _ = cache.Get(2)
+
+			// The hand passes 0, clearing its bit, and evicts 1.
+			So(cache.Put(3, 3), ShouldBeNil)
+			So(evicted, ShouldResemble, []int{1})
+			So(cache.Contains(0), ShouldBeTrue)
+
+			// The hand then passes 2, clearing its bit, and comes around to 0,
+			// which was not read again since its bit was cleared.
+			So(cache.Put(4, 4), ShouldBeNil)
+			So(evicted, ShouldResemble, []int{1, 0})
+			So(cache.Contains(2), ShouldBeTrue)
+			So(cache.Contains(3), ShouldBeTrue)
+		})
+
+		Convey("Given a peek, the item is not referenced", func() {
+			cache, err := NewClock[int, int](2)
+			So(err, ShouldBeNil)
+			So(cache.Put(0, 0), ShouldBeNil)
+			So(cache.Put(1, 1), ShouldBeNil)
+			_, ok := cache.Peek(0)
+			So(ok, ShouldBeTrue)
+
+			So(cache.Put(2, 2), ShouldBeNil)
+			So(cache.Contains(0), ShouldBeFalse)
+		})
+
+		Convey("Given a removed item, its slot is reused", func() {
+			cache, err := NewClock[int, int](2)
+			So(err, ShouldBeNil)
+			var reasons []EvictionReason
+			cache.OnEvict(func(key, item int, reason EvictionReason) {
+				reasons = append(reasons, reason)
+			})
+
+			So(cache.Put(0, 0), ShouldBeNil)
+			So(cache.Put(1, 1), ShouldBeNil)
+			So(cache.Remove(0), ShouldBeNil)
+			So(cache.Remove(0), ShouldEqual, ErrItemNotFound)
+			So(cache.Put(2, 2), ShouldBeNil)
+
+			So(reasons, ShouldResemble, []EvictionReason{EvictedRemoved})
+			So(cache.Len(), ShouldEqual, 2)
+			So(cache.Contains(1), ShouldBeTrue)
+		})
+
+		Convey("Given expired items, they are missed and swept first", func() {
+			clock := newFakeClock()
+			cache, err := NewClock[int, int](2, WithClock(clock), WithDefaultTTL(time.Minute))
+			So(err, ShouldBeNil)
+			var reasons []EvictionReason
+			cache.OnEvict(func(key, item int, reason EvictionReason) {
+				reasons = append(reasons, reason)
+			})
+
+			So(cache.PutWithTTL(0, 0, 0), ShouldBeNil)
+			So(cache.Put(1, 1), ShouldBeNil)
+			clock.Advance(time.Minute)
+
+			_, ok := cache.Get(1)
+			So(ok, ShouldBeFalse)
+			So(cache.Put(1, 2), ShouldBeNil)
+			So(reasons, ShouldResemble, []EvictionReason{EvictedExpired})
+
+			// The hand passes the referenced 0, and evicts 1 once it has expired.
+			clock.Advance(time.Minute)
+			_, _ = cache.Get(0)
+			So(cache.Put(2, 2), ShouldBeNil)
+			So(reasons, ShouldResemble, []EvictionReason{EvictedExpired, EvictedExpired})
+			So(cache.Contains(0), ShouldBeTrue)
+			So(cache.Contains(1), ShouldBeFalse)
+		})
+
+		Convey("Given concurrent gets and puts, the cache stays consistent", func() {
+			cache, err := NewClock[int, int](64)
+			So(err, ShouldBeNil)
+
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 1000; i++ {
+						key := (g*7 + i) % 128
+						if i%4 == 0 {
+							_ = cache.Set(key, i)
+						} else {
+							_, _ = cache.Get(key)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			So(cache.Len(), ShouldBeLessThanOrEqualTo, 64)
+		})
+	})
+}
@@ -0,0 +1,64 @@
+// Command memcached serves a lru_cache over the memcached text protocol, as a
+// local stand-in for memcached in integration tests.
+//
+// Usage:
+//
+//	memcached [-addr :11211] [-m 64] [-c 1048576] [-policy lru]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"lru_cache"
+	"lru_cache/memcached"
+)
+
+func main() {
+	addr := flag.String("addr", ":11211", "TCP address to listen on")
+	megabytes := flag.Int64("m", 64, "maximum memory for items, in megabytes")
+	capacity := flag.Int("c", 1<<20, "maximum number of items")
+	policyName := flag.String("policy", "lru", "eviction policy: lru, lfu, 2q, arc or tinylfu")
+	flag.Parse()
+
+	policy, err := parsePolicy(*policyName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cache, err := lru_cache.New[string, *memcached.Item](*capacity,
+		lru_cache.WithPolicy(policy),
+		lru_cache.WithMaxCost(*megabytes<<20),
+		lru_cache.WithCost(memcached.Cost),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := memcached.NewServer(cache)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		_ = server.Close()
+	}()
+
+	log.Printf("serving memcached protocol on %s", *addr)
+	if err := server.ListenAndServe(*addr); err != memcached.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// parsePolicy returns the policy of the passed name, per Policy.String.
+func parsePolicy(name string) (lru_cache.Policy, error) {
+	for _, policy := range []lru_cache.Policy{lru_cache.LRU, lru_cache.LFU, lru_cache.TwoQueue, lru_cache.ARC, lru_cache.TinyLFU} {
+		if policy.String() == name {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown policy %q", name)
+}
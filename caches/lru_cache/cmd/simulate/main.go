@@ -0,0 +1,232 @@
+// Command simulate replays a trace of cache accesses against the available
+// eviction policies across a range of capacities, and prints their hit ratios,
+// e.g. to choose a policy and capacity before deploying a cache.
+//
+// A trace holds one key per line, or CSV records of a key and its size in bytes.
+// Traces are read as CSV if -format is csv, or by default if their file name ends
+// in .csv; otherwise each whole line is a key, which may contain commas or quotes.
+// Each access is replayed as a Get, followed by a Set if it missed. Capacities
+// are numbers of items, or with -bytes total sizes, and default to fractions of
+// the trace's distinct keys, or bytes.
+//
+// Usage:
+//
+//	simulate [-policies all] [-capacities 100,1000] [-bytes] [-format auto] [-csv out.csv] trace
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// defaultFractions are those of the trace's distinct keys, or bytes, that are
+// simulated unless -capacities is set.
+var defaultFractions = []float64{0.01, 0.02, 0.05, 0.1, 0.2, 0.5}
+
+func main() {
+	policyNames := flag.String("policies", "all", "comma-separated policies: lru, lfu, 2q, arc, tinylfu and clock, or all")
+	capacityList := flag.String("capacities", "", "comma-separated capacities (default: fractions of the trace's distinct keys or bytes)")
+	bySize := flag.Bool("bytes", false, "bound capacities by the total size of items rather than their number")
+	format := flag.String("format", "auto", "trace format: lines, csv, or auto to read traces whose name ends in .csv as CSV")
+	csvPath := flag.String("csv", "", "also write the results as CSV to this file, or to stdout instead of tables if -")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] trace\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	selected, err := selectPolicies(*policyNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	t, err := openTrace(flag.Arg(0), *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	capacities, err := parseCapacities(*capacityList, t, *bySize)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var results []result
+	for _, p := range selected {
+		if *bySize && !p.sized {
+			log.Printf("skipping %s, which cannot bound the size of its items", p.name)
+			continue
+		}
+		for _, capacity := range capacities {
+			r, err := replay(t, p, capacity, *bySize)
+			if err != nil {
+				log.Fatalf("%s at capacity %d: %v", p.name, capacity, err)
+			}
+			results = append(results, r)
+		}
+	}
+
+	if *csvPath != "-" {
+		fmt.Printf("%d accesses of %d distinct keys\n\nhit ratio\n", len(t.accesses), t.distinct())
+		printTable(os.Stdout, results, result.hitRatio)
+		if t.sized {
+			fmt.Printf("\nbyte hit ratio\n")
+			printTable(os.Stdout, results, result.byteHitRatio)
+		}
+	}
+
+	switch *csvPath {
+	case "":
+	case "-":
+		err = writeCSV(os.Stdout, results)
+	default:
+		err = writeCSVFile(*csvPath, results)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// openTrace reads the trace at path, or from stdin if it is -, in the passed
+// format per the -format flag.
+func openTrace(path, format string) (*trace, error) {
+	var isCSV bool
+	switch format {
+	case "auto":
+		isCSV = strings.EqualFold(filepath.Ext(path), ".csv")
+	case "csv":
+		isCSV = true
+	case "lines":
+	default:
+		return nil, fmt.Errorf("unknown trace format %q", format)
+	}
+
+	if path == "-" {
+		return readTrace(os.Stdin, isCSV)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readTrace(f, isCSV)
+}
+
+// parseCapacities parses a comma-separated list of capacities, or if it is
+// empty returns the default fractions of the trace's distinct keys, or bytes.
+func parseCapacities(list string, t *trace, bySize bool) ([]int64, error) {
+	var capacities []int64
+	if list != "" {
+		for _, s := range strings.Split(list, ",") {
+			capacity, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil || capacity <= 0 {
+				return nil, fmt.Errorf("invalid capacity %q", s)
+			}
+			capacities = append(capacities, capacity)
+		}
+	} else {
+		total := int64(t.distinct())
+		if bySize {
+			total = distinctBytes(t)
+		}
+		for _, fraction := range defaultFractions {
+			capacities = append(capacities, max(1, int64(fraction*float64(total))))
+		}
+	}
+
+	slices.Sort(capacities)
+	return slices.Compact(capacities), nil
+}
+
+// distinctBytes returns the total of the last size accessed of each key.
+func distinctBytes(t *trace) (total int64) {
+	sizes := make(map[string]int64)
+	for _, a := range t.accesses {
+		sizes[a.key] = a.size
+	}
+	for _, size := range sizes {
+		total += size
+	}
+	return
+}
+
+// printTable writes a table of a ratio of the results, with a row per capacity
+// and a column per policy.
+func printTable(w io.Writer, results []result, ratio func(result) float64) {
+	var policies []string
+	var capacities []int64
+	ratios := make(map[string]map[int64]float64)
+	for _, r := range results {
+		if _, ok := ratios[r.policy]; !ok {
+			policies = append(policies, r.policy)
+			ratios[r.policy] = make(map[int64]float64)
+		}
+		if !slices.Contains(capacities, r.capacity) {
+			capacities = append(capacities, r.capacity)
+		}
+		ratios[r.policy][r.capacity] = ratio(r)
+	}
+	slices.Sort(capacities)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "capacity\t")
+	for _, p := range policies {
+		fmt.Fprintf(tw, "%s\t", p)
+	}
+	fmt.Fprintln(tw)
+	for _, capacity := range capacities {
+		fmt.Fprintf(tw, "%d\t", capacity)
+		for _, p := range policies {
+			fmt.Fprintf(tw, "%.2f%%\t", 100*ratios[p][capacity])
+		}
+		fmt.Fprintln(tw)
+	}
+	_ = tw.Flush()
+}
+
+// writeCSVFile writes the results as CSV to the file at path.
+func writeCSVFile(path string, results []result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeCSV(f, results); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeCSV writes a header, and then a record per result.
+func writeCSV(w io.Writer, results []result) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"policy", "capacity", "accesses", "hits", "hit_ratio", "bytes", "hit_bytes", "byte_hit_ratio"})
+	for _, r := range results {
+		_ = cw.Write([]string{
+			r.policy,
+			strconv.FormatInt(r.capacity, 10),
+			strconv.Itoa(r.accesses),
+			strconv.Itoa(r.hits),
+			strconv.FormatFloat(r.hitRatio(), 'f', 6, 64),
+			strconv.FormatInt(r.bytes, 10),
+			strconv.FormatInt(r.hitBytes, 10),
+			strconv.FormatFloat(r.byteHitRatio(), 'f', 6, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
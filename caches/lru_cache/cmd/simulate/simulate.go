@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"lru_cache"
+)
+
+// simCache is the subset of the caches' methods that replaying uses.
+type simCache interface {
+	Get(key string) (int64, bool)
+	Set(key string, size int64) error
+}
+
+// policy is a cache under simulation, which is constructed for each capacity.
+type policy struct {
+	name string
+	// sized is false if the cache cannot bound the total size of its items.
+	sized bool
+	new   func(capacity int, maxBytes int64) (simCache, error)
+}
+
+// policies returns every available policy: those of lru_cache.Cache, and ClockCache.
+func policies() []policy {
+	var all []policy
+	for _, p := range []lru_cache.Policy{lru_cache.LRU, lru_cache.LFU, lru_cache.TwoQueue, lru_cache.ARC, lru_cache.TinyLFU} {
+		all = append(all, policy{
+			name:  p.String(),
+			sized: true,
+			new: func(capacity int, maxBytes int64) (simCache, error) {
+				opts := []lru_cache.Option{lru_cache.WithPolicy(p)}
+				if maxBytes > 0 {
+					opts = append(opts,
+						lru_cache.WithMaxCost(maxBytes),
+						lru_cache.WithCost(func(size int64) int64 { return size }),
+					)
+				}
+				return lru_cache.New[string, int64](capacity, opts...)
+			},
+		})
+	}
+
+	return append(all, policy{
+		name: "clock",
+		new: func(capacity int, _ int64) (simCache, error) {
+			return lru_cache.NewClock[string, int64](capacity)
+		},
+	})
+}
+
+// selectPolicies returns the policies of the passed comma-separated names, or
+// every policy for "all".
+func selectPolicies(names string) ([]policy, error) {
+	all := policies()
+	if names == "all" {
+		return all, nil
+	}
+
+	var selected []policy
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, p := range all {
+			if p.name == name {
+				selected = append(selected, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown policy %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// result is the outcome of replaying a trace against a policy of a capacity.
+type result struct {
+	policy   string
+	capacity int64
+	accesses int
+	hits     int
+	// bytes and hitBytes are the total size of the accesses and of the hits.
+	bytes    int64
+	hitBytes int64
+}
+
+// hitRatio returns the fraction of accesses that hit.
+func (r result) hitRatio() float64 {
+	return float64(r.hits) / float64(r.accesses)
+}
+
+// byteHitRatio returns the fraction of the accessed bytes that hit.
+func (r result) byteHitRatio() float64 {
+	return float64(r.hitBytes) / float64(r.bytes)
+}
+
+// replay runs the trace against a new cache of the policy, which holds capacity
+// items, or if bySize capacity bytes of items. Each access is a Get, which is
+// followed by a Set of the key if it missed, as by a cache-aside client.
+func replay(t *trace, p policy, capacity int64, bySize bool) (result, error) {
+	items, maxBytes := int(capacity), int64(0)
+	if bySize {
+		// The cache is bounded by size alone, so it can hold every key.
+		items, maxBytes = t.distinct(), capacity
+	}
+
+	cache, err := p.new(items, maxBytes)
+	if err != nil {
+		return result{}, err
+	}
+
+	r := result{
+		policy:   p.name,
+		capacity: capacity,
+		accesses: len(t.accesses),
+	}
+	for _, a := range t.accesses {
+		r.bytes += a.size
+		if _, ok := cache.Get(a.key); ok {
+			r.hits++
+			r.hitBytes += a.size
+			continue
+		}
+
+		// Items larger than the cache are simply not cached.
+		_ = cache.Set(a.key, a.size)
+	}
+	return r, nil
+}
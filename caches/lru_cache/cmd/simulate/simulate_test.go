@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSimulate(t *testing.T) {
+	Convey("Simulator tests", t, func() {
+		Convey("Given a trace of keys, each access has size one", func() {
+			tr, err := readTrace(strings.NewReader("a\n\n# comment\nb\na\n"), false)
+			So(err, ShouldBeNil)
+			So(tr.sized, ShouldBeFalse)
+			So(tr.accesses, ShouldResemble, []access{{"a", 1}, {"b", 1}, {"a", 1}})
+			So(tr.distinct(), ShouldEqual, 2)
+		})
+
+		Convey("Given a CSV trace with a header, sizes are read", func() {
+			tr, err := readTrace(strings.NewReader("key,size\na,10\nb, 20\n"), true)
+			So(err, ShouldBeNil)
+			So(tr.sized, ShouldBeTrue)
+			So(tr.accesses, ShouldResemble, []access{{"a", 10}, {"b", 20}})
+		})
+
+		Convey("Given an invalid trace, it is rejected", func() {
+			for _, input := range []string{"", "a,x\nb,y\n", "a,0\n", "a,1,2\n", ",1\n"} {
+				_, err := readTrace(strings.NewReader(input), true)
+				So(err, ShouldNotBeNil)
+			}
+			_, err := readTrace(strings.NewReader("\n# comment\n"), false)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Given a trace of keys with commas, quotes and spaces, they are kept whole", func() {
+			input := "/search?q=a,b\n\"quoted\" key\n /search?q=a,b\r\n/search?q=a,b"
+			tr, err := readTrace(strings.NewReader(input), false)
+			So(err, ShouldBeNil)
+			So(tr.accesses, ShouldResemble, []access{
+				{"/search?q=a,b", 1}, {"\"quoted\" key", 1}, {" /search?q=a,b", 1}, {"/search?q=a,b", 1},
+			})
+
+			selected, err := selectPolicies("lru")
+			So(err, ShouldBeNil)
+			r, err := replay(tr, selected[0], 3, false)
+			So(err, ShouldBeNil)
+			So(r.hits, ShouldEqual, 1)
+		})
+
+		Convey("Given a replay, hits are counted per policy and capacity", func() {
+			tr, err := readTrace(strings.NewReader("a\nb\na\nc\na\nb\n"), false)
+			So(err, ShouldBeNil)
+			selected, err := selectPolicies("lru")
+			So(err, ShouldBeNil)
+
+			// a hits twice; b is evicted by c before it is read again.
+			r, err := replay(tr, selected[0], 2, false)
+			So(err, ShouldBeNil)
+			So(r.hits, ShouldEqual, 2)
+			So(r.hitRatio(), ShouldAlmostEqual, 2.0/6)
+
+			r, err = replay(tr, selected[0], 3, false)
+			So(err, ShouldBeNil)
+			So(r.hits, ShouldEqual, 3)
+		})
+
+		Convey("Given a replay by size, items are bounded by their total size", func() {
+			tr, err := readTrace(strings.NewReader("a,6\nb,6\na,6\nc,1\nc,1\n"), true)
+			So(err, ShouldBeNil)
+			selected, err := selectPolicies("lru")
+			So(err, ShouldBeNil)
+
+			r, err := replay(tr, selected[0], 10, true)
+			So(err, ShouldBeNil)
+			So(r.hits, ShouldEqual, 1)
+			So(r.byteHitRatio(), ShouldAlmostEqual, 1.0/20)
+		})
+
+		Convey("Given unknown policies, they are rejected", func() {
+			_, err := selectPolicies("lru,fifo")
+			So(err, ShouldNotBeNil)
+			all, err := selectPolicies("all")
+			So(err, ShouldBeNil)
+			So(len(all), ShouldEqual, 6)
+		})
+
+		Convey("Given results, they are written as CSV and tables", func() {
+			results := []result{
+				{policy: "lru", capacity: 10, accesses: 4, hits: 1, bytes: 4, hitBytes: 1},
+				{policy: "arc", capacity: 10, accesses: 4, hits: 2, bytes: 4, hitBytes: 2},
+			}
+
+			var buf bytes.Buffer
+			So(writeCSV(&buf, results), ShouldBeNil)
+			So(buf.String(), ShouldStartWith, "policy,capacity,accesses,hits,hit_ratio,")
+			So(buf.String(), ShouldContainSubstring, "arc,10,4,2,0.500000,4,2,0.500000\n")
+
+			buf.Reset()
+			printTable(&buf, results, result.hitRatio)
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			So(lines, ShouldHaveLength, 2)
+			So(strings.Fields(lines[0]), ShouldResemble, []string{"capacity", "lru", "arc"})
+			So(strings.Fields(lines[1]), ShouldResemble, []string{"10", "25.00%", "50.00%"})
+		})
+	})
+}
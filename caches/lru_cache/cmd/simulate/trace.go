@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// access is a request for a key in a trace, whose size is one if the trace
+// has no sizes.
+type access struct {
+	key  string
+	size int64
+}
+
+// trace is a sequence of accesses, and whether they have sizes.
+type trace struct {
+	accesses []access
+	sized    bool
+}
+
+// distinct returns the number of distinct keys in the trace.
+func (t *trace) distinct() int {
+	keys := make(map[string]struct{})
+	for _, a := range t.accesses {
+		keys[a.key] = struct{}{}
+	}
+	return len(keys)
+}
+
+// readTrace parses a trace of one key per line, whose whole line is the key, or
+// if isCSV is set of CSV records of a key and its size in bytes. Blank lines and
+// lines starting with # are skipped.
+func readTrace(r io.Reader, isCSV bool) (*trace, error) {
+	var t *trace
+	var err error
+	if isCSV {
+		t, err = readCSVTrace(r)
+	} else {
+		t, err = readLineTrace(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.accesses) == 0 {
+		return nil, errors.New("trace is empty")
+	}
+	return t, nil
+}
+
+// readLineTrace parses a trace of one key per line, per readTrace.
+func readLineTrace(r io.Reader) (*trace, error) {
+	t := &trace{}
+	keys := make(map[string]string)
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		key := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if key != "" && !strings.HasPrefix(key, "#") {
+			t.accesses = append(t.accesses, access{key: intern(keys, key), size: 1})
+		}
+		if err != nil {
+			return t, nil
+		}
+	}
+}
+
+// readCSVTrace parses a trace of CSV records, per readTrace. A first record
+// whose size is not a number is taken to be a header.
+func readCSVTrace(r io.Reader) (*trace, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.ReuseRecord = true
+
+	t := &trace{}
+	keys := make(map[string]string)
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		key := record[0]
+		if key == "" {
+			return nil, fmt.Errorf("line %d: empty key", line)
+		}
+		a := access{size: 1}
+		switch len(record) {
+		case 1:
+		case 2:
+			size, err := strconv.ParseInt(strings.TrimSpace(record[1]), 10, 64)
+			if err != nil && first {
+				continue
+			}
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("line %d: invalid size %q", line, record[1])
+			}
+			a.size = size
+			t.sized = true
+		default:
+			return nil, fmt.Errorf("line %d: expected a key and optional size, got %d fields", line, len(record))
+		}
+
+		a.key = intern(keys, key)
+		t.accesses = append(t.accesses, a)
+	}
+}
+
+// intern returns the copy of key in keys, adding one if it is missing, since
+// traces repeat their keys by design.
+func intern(keys map[string]string, key string) string {
+	if interned, ok := keys[key]; ok {
+		return interned
+	}
+	interned := strings.Clone(key)
+	keys[interned] = interned
+	return interned
+}
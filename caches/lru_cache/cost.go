@@ -0,0 +1,58 @@
+package lru_cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCost is returned when the cost function passed to WithCost returns
+// a cost of zero or less for an item, which would let the cache exceed MaxCost.
+var ErrInvalidCost error = errors.New("item cost is not positive")
+
+// ItemTooLargeError is returned when an item's cost exceeds the cache's entire MaxCost.
+type ItemTooLargeError struct {
+	Cost    int64
+	MaxCost int64
+}
+
+func (err *ItemTooLargeError) Error() string {
+	return fmt.Sprintf("item cost %d exceeds max cost %d", err.Cost, err.MaxCost)
+}
+
+// costOf returns the item's cost, or an *ItemTooLargeError if it can never fit,
+// or ErrInvalidCost if it is not positive.
+func (cache *Cache[K, V]) costOf(item V) (int64, error) {
+	cost := int64(1)
+	if cache.costFunc != nil {
+		cost = cache.costFunc(item)
+	}
+	if cost <= 0 {
+		return 0, ErrInvalidCost
+	}
+
+	if cache.maxCost > 0 && cost > cache.maxCost {
+		return 0, &ItemTooLargeError{
+			Cost:    cost,
+			MaxCost: cache.maxCost,
+		}
+	}
+
+	return cost, nil
+}
+
+// Len returns the number of cached items, including any that have expired
+// but are not yet removed.
+func (cache *Cache[K, V]) Len() int {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	return cache.itemList.count
+}
+
+// Cost returns the total cost of the cached items.
+func (cache *Cache[K, V]) Cost() int64 {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	return cache.cost
+}
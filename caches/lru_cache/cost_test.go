@@ -0,0 +1,111 @@
+package lru_cache
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func byteCost(b []byte) int64 {
+	return int64(len(b))
+}
+
+func TestCost(t *testing.T) {
+	Convey("Cost-weighted capacity tests", t, func() {
+		Convey("Given a byte budget, Put evicts until the total cost fits", func() {
+			cache, err := New[string, []byte](100, WithMaxCost(10), WithCost(byteCost))
+			So(err, ShouldBeNil)
+
+			evicted := []string{}
+			cache.OnEvict(func(key string, item []byte, reason EvictionReason) {
+				evicted = append(evicted, key)
+			})
+
+			So(cache.Put("a", make([]byte, 4)), ShouldBeNil)
+			So(cache.Put("b", make([]byte, 4)), ShouldBeNil)
+			So(cache.Len(), ShouldEqual, 2)
+			So(cache.Cost(), ShouldEqual, 8)
+
+			// Both a and b must go to fit c.
+			_, _ = cache.Get("a")
+			So(cache.Put("c", make([]byte, 9)), ShouldBeNil)
+			So(evicted, ShouldResemble, []string{"b", "a"})
+			So(cache.Len(), ShouldEqual, 1)
+			So(cache.Cost(), ShouldEqual, 9)
+
+			So(cache.Remove("c"), ShouldBeNil)
+			So(cache.Cost(), ShouldEqual, 0)
+		})
+
+		Convey("Given an item larger than the budget, Put rejects it", func() {
+			cache, err := New[string, []byte](100, WithMaxCost(10), WithCost(byteCost))
+			So(err, ShouldBeNil)
+			So(cache.Put("a", make([]byte, 4)), ShouldBeNil)
+
+			err = cache.Put("big", make([]byte, 11))
+			var tooLarge *ItemTooLargeError
+			So(errors.As(err, &tooLarge), ShouldBeTrue)
+			So(tooLarge.Cost, ShouldEqual, 11)
+			So(tooLarge.MaxCost, ShouldEqual, 10)
+			So(err.Error(), ShouldEqual, "item cost 11 exceeds max cost 10")
+
+			// Nothing was evicted to make room.
+			So(cache.Len(), ShouldEqual, 1)
+			_, ok := cache.Get("big")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Given an item whose cost is not positive, Put rejects it", func() {
+			cache, err := New[string, []byte](100, WithMaxCost(10), WithCost(byteCost))
+			So(err, ShouldBeNil)
+			So(cache.Put("a", make([]byte, 4)), ShouldBeNil)
+
+			So(cache.Put("empty", nil), ShouldEqual, ErrInvalidCost)
+			So(cache.Set("a", []byte{}), ShouldEqual, ErrInvalidCost)
+			So(cache.Len(), ShouldEqual, 1)
+			So(cache.Cost(), ShouldEqual, 4)
+
+			negative, err := New[int, int](100, WithMaxCost(10), WithCost(func(item int) int64 { return -1 }))
+			So(err, ShouldBeNil)
+			So(negative.Put(1, 1), ShouldEqual, ErrInvalidCost)
+			So(negative.Cost(), ShouldEqual, 0)
+		})
+
+		Convey("Given a budget without a cost function, items cost one", func() {
+			cache, err := New[int, int](100, WithMaxCost(3))
+			So(err, ShouldBeNil)
+			for i := 0; i < 5; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+			So(cache.Len(), ShouldEqual, 3)
+			So(cache.Cost(), ShouldEqual, 3)
+		})
+
+		Convey("Item count capacity still applies with a budget", func() {
+			cache, err := New[string, []byte](2, WithMaxCost(100), WithCost(byteCost))
+			So(err, ShouldBeNil)
+			for _, key := range []string{"a", "b", "c"} {
+				So(cache.Put(key, []byte(key)), ShouldBeNil)
+			}
+			So(cache.Len(), ShouldEqual, 2)
+			So(cache.Cost(), ShouldEqual, 2)
+		})
+
+		Convey("A sharded cache divides its budget across shards", func() {
+			cache, err := NewSharded[string, []byte](4, 100, WithMaxCost(10), WithCost(byteCost))
+			So(err, ShouldBeNil)
+			So(cache.shards[0].maxCost, ShouldEqual, 3)
+			So(cache.Put("a", []byte("abc")), ShouldBeNil)
+			So(cache.Len(), ShouldEqual, 1)
+			So(cache.Cost(), ShouldEqual, 3)
+		})
+
+		Convey("Invalid cost options are rejected", func() {
+			_, err := New[string, int](1, WithMaxCost(-1))
+			So(err, ShouldBeError, ErrInvalidOption)
+			_, err = New[string, int](1, WithCost(byteCost))
+			So(err, ShouldBeError, ErrInvalidOption)
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package lru_cache
+
+// EvictionReason describes why an item left the cache.
+type EvictionReason int
+
+const (
+	// EvictedCapacity items were least-recently-used when the cache was over capacity.
+	EvictedCapacity EvictionReason = iota + 1
+	// EvictedRemoved items were explicitly removed.
+	EvictedRemoved
+	// EvictedExpired items outlived their TTL.
+	EvictedExpired
+	// EvictedReplaced items were overwritten by a new item under the same key.
+	EvictedReplaced
+)
+
+func (reason EvictionReason) String() string {
+	switch reason {
+	case EvictedCapacity:
+		return "capacity"
+	case EvictedRemoved:
+		return "removed"
+	case EvictedExpired:
+		return "expired"
+	case EvictedReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+type eviction[K comparable, V any] struct {
+	key    K
+	item   V
+	reason EvictionReason
+}
+
+// OnEvict registers fn to be called for every item that leaves the cache,
+// replacing any previous callback; a nil fn removes it. Callbacks are made
+// without the cache lock held, so fn may safely call back into the cache,
+// but callbacks from concurrent operations may arrive in any order.
+func (cache *Cache[K, V]) OnEvict(fn func(key K, item V, reason EvictionReason)) {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	cache.onEvict = fn
+}
+
+// notifyEvicted counts target's eviction, and records it for the callback if one
+// is registered. The caller must hold the write lock.
+func (cache *Cache[K, V]) notifyEvicted(target *node[K, V], reason EvictionReason) {
+	cache.counters.evictions[reason].Add(1)
+	if cache.onEvict == nil {
+		return
+	}
+
+	cache.evicted = append(cache.evicted, eviction[K, V]{
+		key:    target.key,
+		item:   target.item,
+		reason: reason,
+	})
+}
+
+// unlock releases the write lock and then makes any pending eviction callbacks.
+func (cache *Cache[K, V]) unlock() {
+	evicted, onEvict := cache.evicted, cache.onEvict
+	cache.evicted = nil
+	cache.mu.Unlock()
+
+	for _, e := range evicted {
+		onEvict(e.key, e.item, e.reason)
+	}
+}
@@ -0,0 +1,99 @@
+package lru_cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOnEvict(t *testing.T) {
+	Convey("Eviction callback tests", t, func() {
+		clock := newFakeClock()
+		cache, err := New[string, int](2, WithClock(clock))
+		So(err, ShouldBeNil)
+
+		evictions := map[string]EvictionReason{}
+		cache.OnEvict(func(key string, item int, reason EvictionReason) {
+			evictions[key] = reason
+		})
+
+		Convey("Items evicted over capacity are reported", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("b", 2), ShouldBeNil)
+			So(cache.Put("c", 3), ShouldBeNil)
+			So(evictions, ShouldResemble, map[string]EvictionReason{"a": EvictedCapacity})
+		})
+
+		Convey("Removed items are reported", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Remove("a"), ShouldBeNil)
+			So(evictions, ShouldResemble, map[string]EvictionReason{"a": EvictedRemoved})
+		})
+
+		Convey("Expired items are reported whether accessed, swept or replaced", func() {
+			So(cache.PutWithTTL("a", 1, time.Second), ShouldBeNil)
+			So(cache.PutWithTTL("b", 2, time.Second), ShouldBeNil)
+			clock.Advance(time.Second)
+
+			_, ok := cache.Get("a")
+			So(ok, ShouldBeFalse)
+			So(cache.Put("b", 3), ShouldBeNil)
+			So(evictions, ShouldResemble, map[string]EvictionReason{"a": EvictedExpired, "b": EvictedExpired})
+
+			So(cache.PutWithTTL("c", 4, time.Second), ShouldBeNil)
+			clock.Advance(time.Second)
+			So(cache.DeleteExpired(), ShouldEqual, 1)
+			So(evictions["c"], ShouldEqual, EvictedExpired)
+		})
+
+		Convey("Items replaced by a store load are reported", func() {
+			store := NewMemoryStore[string, int]()
+			So(store.Save("a", 2), ShouldBeNil)
+			cache, err := New[string, int](2, WithClock(clock), WithStore[string, int](store, ReadThrough))
+			So(err, ShouldBeNil)
+
+			reasons := []EvictionReason{}
+			cache.OnEvict(func(key string, item int, reason EvictionReason) {
+				reasons = append(reasons, reason)
+			})
+			So(cache.PutWithTTL("a", 1, time.Second), ShouldBeNil)
+			clock.Advance(time.Second)
+			val, ok := cache.Get("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 2)
+			So(reasons, ShouldResemble, []EvictionReason{EvictedExpired})
+		})
+
+		Convey("Callbacks may call back into the cache without deadlock", func() {
+			cache.OnEvict(func(key string, item int, reason EvictionReason) {
+				if key == "a" {
+					_ = cache.Put("a'", item)
+				}
+			})
+
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("b", 2), ShouldBeNil)
+			// Evicts a, whose callback adds a' and evicts b.
+			So(cache.Put("c", 3), ShouldBeNil)
+			val, ok := cache.Get("a'")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+			_, ok = cache.Get("b")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("A nil callback disables reporting", func() {
+			cache.OnEvict(nil)
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Remove("a"), ShouldBeNil)
+			So(evictions, ShouldBeEmpty)
+		})
+
+		Convey("Reasons format as strings", func() {
+			So(EvictedCapacity.String(), ShouldEqual, "capacity")
+			So(EvictedReplaced.String(), ShouldEqual, "replaced")
+			So(EvictionReason(0).String(), ShouldEqual, "unknown")
+		})
+	})
+}
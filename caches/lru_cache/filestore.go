@@ -0,0 +1,160 @@
+package lru_cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrKeyCollision is returned by FileStore.Save when key's file holds the value of
+// another key, whose encoding has the same hash.
+var ErrKeyCollision error = errors.New("file store key collision")
+
+// FileStore is a Store that saves each value as a gob-encoded file in a directory.
+// Files are named by the SHA-256 hash of their gob-encoded key, so keys of any
+// length may be stored, and hold the key itself ahead of the value, such that
+// keys whose names collide are told apart. Keys must therefore decode from gob
+// to an equal key, e.g. they must not be structs with unexported fields.
+// FileStore is safe for concurrent use so long as concurrent writers do not share
+// a key, since each save is an atomic file rename.
+type FileStore[K comparable, V any] struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore in dir, which is created if it does not exist.
+func NewFileStore[K comparable, V any](dir string) (*FileStore[K, V], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore[K, V]{
+		dir: dir,
+	}, nil
+}
+
+// path returns the file for key, or an error if the key cannot be encoded.
+func (store *FileStore[K, V]) path(key K) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(key); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return filepath.Join(store.dir, "key-"+hex.EncodeToString(sum[:])), nil
+}
+
+// open opens key's file, and decodes the key stored in it. It returns
+// ErrItemNotFound if there is no file, and ok is false if it holds another key.
+func (store *FileStore[K, V]) open(key K) (f *os.File, dec *gob.Decoder, ok bool, err error) {
+	path, err := store.path(key)
+	if err != nil {
+		return
+	}
+	f, err = os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = ErrItemNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	dec = gob.NewDecoder(f)
+	var stored K
+	if err = dec.Decode(&stored); err != nil {
+		_ = f.Close()
+		return nil, nil, false, err
+	}
+	return f, dec, stored == key, nil
+}
+
+// Load decodes the value stored under key, or returns ErrItemNotFound.
+func (store *FileStore[K, V]) Load(key K) (value V, err error) {
+	f, dec, ok, err := store.open(key)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if !ok {
+		err = ErrItemNotFound
+		return
+	}
+
+	err = dec.Decode(&value)
+	return
+}
+
+// Save encodes key and value to a temporary file, which is then renamed over
+// key's file, or returns ErrKeyCollision if the file holds another key.
+func (store *FileStore[K, V]) Save(key K, value V) (err error) {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err = store.checkCollision(key); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(store.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	enc := gob.NewEncoder(tmp)
+	if err = enc.Encode(key); err == nil {
+		err = enc.Encode(value)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkCollision returns ErrKeyCollision if key's file holds another key.
+func (store *FileStore[K, V]) checkCollision(key K) error {
+	f, _, ok, err := store.open(key)
+	if errors.Is(err, ErrItemNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	if !ok {
+		return ErrKeyCollision
+	}
+	return nil
+}
+
+// Delete removes key's file, or returns ErrItemNotFound, including if the file
+// holds another key.
+func (store *FileStore[K, V]) Delete(key K) error {
+	f, _, ok, err := store.open(key)
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	if !ok {
+		return ErrItemNotFound
+	}
+
+	err = os.Remove(f.Name())
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrItemNotFound
+	}
+	return err
+}
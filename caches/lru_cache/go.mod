@@ -1,6 +1,6 @@
 module lru_cache
 
-go 1.19
+go 1.24
 
 require github.com/smartystreets/goconvey v1.7.2
 
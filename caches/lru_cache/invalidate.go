@@ -0,0 +1,169 @@
+package lru_cache
+
+// PutWithTags adds the passed item to the cache under key per Put, and attaches
+// the passed tags to it, such that it is removed by InvalidateTag of any of them.
+// Tags are kept if the item is replaced by Set or refreshed, but are not saved
+// by SaveTo.
+func (cache *Cache[K, V]) PutWithTags(key K, item V, tags ...string) error {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	if err := cache.put(key, item, cache.defaultTTL); err != nil {
+		return err
+	}
+
+	target := cache.itemMap[key]
+	target.tags = tags
+	for _, tag := range tags {
+		keys, ok := cache.tagged[tag]
+		if !ok {
+			keys = make(map[K]struct{})
+			cache.tagged[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	cache.evictOverCapacity()
+
+	return nil
+}
+
+// InvalidateTag removes every item with the passed tag, and returns the number
+// removed. Removed items are reported to OnEvict as EvictedRemoved, but unlike
+// Remove, they are not deleted from the cache's store. InvalidateTag takes time
+// proportional to the number of items removed, not the size of the cache.
+func (cache *Cache[K, V]) InvalidateTag(tag string) (removed int) {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	// Removal deletes each key from the tag's index, which is safe while ranging.
+	for key := range cache.tagged[tag] {
+		cache.removeNode(cache.itemMap[key], EvictedRemoved)
+		removed++
+	}
+
+	return
+}
+
+// InvalidatePrefix removes every item of the string-keyed cache whose key has
+// the passed prefix, and returns the number removed, per InvalidateTag. The keys
+// of string-keyed caches are indexed as they are added and removed, such that
+// InvalidatePrefix takes time proportional to the number of items removed, not
+// the size of the cache.
+func InvalidatePrefix[V any](cache *Cache[string, V], prefix string) (removed int) {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	for _, key := range cache.prefixes.find(prefix) {
+		cache.removeNode(cache.itemMap[key], EvictedRemoved)
+		removed++
+	}
+
+	return
+}
+
+// newKeyIndex returns a prefix index for caches whose keys are strings, and
+// otherwise nil.
+func newKeyIndex[K comparable]() *prefixIndex {
+	var key K
+	if _, ok := any(key).(string); !ok {
+		return nil
+	}
+	return newPrefixIndex()
+}
+
+// indexKey adds key to the prefix index, if any. The caller must hold the write lock.
+func (cache *Cache[K, V]) indexKey(key K) {
+	if cache.prefixes != nil {
+		// The index is only built for string keys.
+		cache.prefixes.add(any(key).(string))
+	}
+}
+
+// unindex removes target from the tag and prefix indexes. The caller must hold the write lock.
+func (cache *Cache[K, V]) unindex(target *node[K, V]) {
+	for _, tag := range target.tags {
+		keys := cache.tagged[tag]
+		delete(keys, target.key)
+		if len(keys) == 0 {
+			delete(cache.tagged, tag)
+		}
+	}
+
+	if cache.prefixes != nil {
+		cache.prefixes.remove(any(target.key).(string))
+	}
+}
+
+// prefixIndex is a trie of strings, which finds those with a given prefix
+// without scanning the others.
+type prefixIndex struct {
+	root *trieNode
+}
+
+type trieNode struct {
+	children map[byte]*trieNode
+	// terminal is true if a string ends at this node.
+	terminal bool
+}
+
+func newPrefixIndex() *prefixIndex {
+	return &prefixIndex{
+		root: &trieNode{},
+	}
+}
+
+func (index *prefixIndex) add(s string) {
+	current := index.root
+	for i := 0; i < len(s); i++ {
+		child, ok := current.children[s[i]]
+		if !ok {
+			if current.children == nil {
+				current.children = make(map[byte]*trieNode)
+			}
+			child = &trieNode{}
+			current.children[s[i]] = child
+		}
+		current = child
+	}
+	current.terminal = true
+}
+
+// remove removes s, and prunes the nodes left without strings.
+func (index *prefixIndex) remove(s string) {
+	index.root.remove(s)
+}
+
+// remove removes s below n, and returns true if n is left empty.
+func (n *trieNode) remove(s string) bool {
+	if s == "" {
+		n.terminal = false
+	} else if child, ok := n.children[s[0]]; ok && child.remove(s[1:]) {
+		delete(n.children, s[0])
+	}
+	return !n.terminal && len(n.children) == 0
+}
+
+// find returns every string with the passed prefix.
+func (index *prefixIndex) find(prefix string) (found []string) {
+	current := index.root
+	for i := 0; i < len(prefix) && current != nil; i++ {
+		current = current.children[prefix[i]]
+	}
+	if current == nil {
+		return
+	}
+
+	current.collect([]byte(prefix), &found)
+	return
+}
+
+// collect appends every string below n, each of which begins with path.
+func (n *trieNode) collect(path []byte, found *[]string) {
+	if n.terminal {
+		*found = append(*found, string(path))
+	}
+	for c, child := range n.children {
+		child.collect(append(path, c), found)
+	}
+}
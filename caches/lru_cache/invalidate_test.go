@@ -0,0 +1,102 @@
+package lru_cache
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInvalidation(t *testing.T) {
+	Convey("Invalidation tests", t, func() {
+		cache, err := New[string, int](4)
+		So(err, ShouldBeNil)
+
+		var removed []string
+		cache.OnEvict(func(key string, item int, reason EvictionReason) {
+			if reason == EvictedRemoved {
+				removed = append(removed, key)
+			}
+		})
+
+		Convey("Given tagged items, InvalidateTag removes those with the tag", func() {
+			So(cache.PutWithTags("user:1", 1, "users", "org:a"), ShouldBeNil)
+			So(cache.PutWithTags("user:2", 2, "users", "org:b"), ShouldBeNil)
+			So(cache.PutWithTags("org:a", 3, "org:a"), ShouldBeNil)
+			So(cache.Put("other", 4), ShouldBeNil)
+			So(cache.PutWithTags("user:1", 5, "users"), ShouldEqual, ErrDuplicateItem)
+
+			So(cache.InvalidateTag("org:a"), ShouldEqual, 2)
+			slices.Sort(removed)
+			So(removed, ShouldResemble, []string{"org:a", "user:1"})
+			So(cache.Keys(), ShouldResemble, []string{"other", "user:2"})
+
+			// The removed items are no longer indexed under their other tags.
+			So(cache.tagged["users"], ShouldHaveLength, 1)
+			So(cache.tagged, ShouldNotContainKey, "org:a")
+			So(cache.InvalidateTag("org:a"), ShouldEqual, 0)
+			So(cache.InvalidateTag("users"), ShouldEqual, 1)
+			So(cache.tagged, ShouldBeEmpty)
+		})
+
+		Convey("Given a cache whose keys are not strings, it has no prefix index", func() {
+			cache, err := New[int, int](10)
+			So(err, ShouldBeNil)
+			So(cache.Put(1, 1), ShouldBeNil)
+			So(cache.prefixes, ShouldBeNil)
+		})
+
+		Convey("Given a sharded cache, InvalidatePrefixSharded removes keys from every shard", func() {
+			sharded, err := NewSharded[string, int](4, 100)
+			So(err, ShouldBeNil)
+			for i := 0; i < 20; i++ {
+				So(sharded.Put(fmt.Sprint("user:", i), i), ShouldBeNil)
+				So(sharded.Put(fmt.Sprint("group:", i), i), ShouldBeNil)
+			}
+
+			So(InvalidatePrefixSharded(sharded, "user:1"), ShouldEqual, 11)
+			So(sharded.Len(), ShouldEqual, 29)
+			So(InvalidatePrefixSharded(sharded, "user:"), ShouldEqual, 9)
+			So(sharded.Len(), ShouldEqual, 20)
+		})
+
+		Convey("Given tagged items that are evicted, they are removed from the index", func() {
+			for _, key := range []string{"a", "b", "c", "d", "e"} {
+				So(cache.PutWithTags(key, 0, "letters"), ShouldBeNil)
+			}
+			So(cache.tagged["letters"], ShouldHaveLength, 4)
+			So(cache.tagged["letters"], ShouldNotContainKey, "a")
+		})
+
+		Convey("Given a tagged item that is Set, it keeps its tags", func() {
+			So(cache.PutWithTags("a", 1, "t"), ShouldBeNil)
+			So(cache.Set("a", 2), ShouldBeNil)
+			So(cache.InvalidateTag("t"), ShouldEqual, 1)
+			So(cache.Contains("a"), ShouldBeFalse)
+		})
+
+		Convey("Given string keys, InvalidatePrefix removes those with the prefix", func() {
+			for _, key := range []string{"user:1", "user:10", "user:2", "group:1"} {
+				So(cache.Put(key, 0), ShouldBeNil)
+			}
+			// The keys are indexed as they are added.
+			So(cache.prefixes.find("group"), ShouldResemble, []string{"group:1"})
+
+			So(InvalidatePrefix(cache, "user:1"), ShouldEqual, 2)
+			So(cache.Keys(), ShouldResemble, []string{"group:1", "user:2"})
+			So(InvalidatePrefix(cache, "nobody"), ShouldEqual, 0)
+
+			// The index follows later insertions and removals.
+			So(cache.Put("user:3", 0), ShouldBeNil)
+			So(cache.Put("user", 0), ShouldBeNil)
+			So(cache.Remove("user:2"), ShouldBeNil)
+			So(cache.prefixes.find("user:"), ShouldResemble, []string{"user:3"})
+
+			So(InvalidatePrefix(cache, "user"), ShouldEqual, 2)
+			So(cache.Keys(), ShouldResemble, []string{"group:1"})
+			So(InvalidatePrefix(cache, ""), ShouldEqual, 1)
+			So(cache.prefixes.root.children, ShouldBeEmpty)
+		})
+	})
+}
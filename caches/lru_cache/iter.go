@@ -0,0 +1,85 @@
+package lru_cache
+
+import (
+	"iter"
+	"time"
+)
+
+// entry is a copy of a cached item, taken such that it may be read without the lock.
+type entry[K comparable, V any] struct {
+	key     K
+	item    V
+	expires time.Time
+}
+
+// entries returns copies of the unexpired items, from most to least recently used.
+func (cache *Cache[K, V]) entries() []entry[K, V] {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	entries := make([]entry[K, V], 0, cache.itemList.count)
+	for current := cache.itemList.head; current != nil; current = current.next {
+		if cache.expired(current) {
+			continue
+		}
+		entries = append(entries, entry[K, V]{
+			key:     current.key,
+			item:    current.item,
+			expires: current.expires,
+		})
+	}
+	return entries
+}
+
+// Keys returns the keys of the unexpired items, from most to least recently used.
+// Like All, it does not move items to the front of the cache.
+func (cache *Cache[K, V]) Keys() []K {
+	entries := cache.entries()
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// Items returns the unexpired items, from most to least recently used.
+// Like All, it does not move items to the front of the cache.
+func (cache *Cache[K, V]) Items() []V {
+	entries := cache.entries()
+	items := make([]V, len(entries))
+	for i, e := range entries {
+		items[i] = e.item
+	}
+	return items
+}
+
+// All returns an iterator over the keys and items of the unexpired items, from
+// most to least recently used. Iteration neither moves items to the front of the
+// cache nor counts as hits, so it does not disturb the eviction order.
+//
+// Each iteration copies the cache's items under the read lock when it starts,
+// and yields them without the lock held. It therefore sees a consistent snapshot,
+// the loop body may safely call into the cache, and items put, removed or evicted
+// during the iteration are not reflected in it.
+func (cache *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, e := range cache.entries() {
+			if !yield(e.key, e.item) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the keys and items of the unexpired items,
+// from least to most recently used, which otherwise behaves as All.
+func (cache *Cache[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		entries := cache.entries()
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !yield(entries[i].key, entries[i].item) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package lru_cache
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIteration(t *testing.T) {
+	Convey("Iteration tests", t, func() {
+		clock := newFakeClock()
+		cache, err := New[string, int](10, WithClock(clock))
+		So(err, ShouldBeNil)
+		for i, key := range []string{"a", "b", "c", "d"} {
+			So(cache.Put(key, i), ShouldBeNil)
+		}
+		_, _ = cache.Get("b")
+
+		Convey("Given an empty cache, nothing is iterated", func() {
+			empty, err := New[string, int](10)
+			So(err, ShouldBeNil)
+			So(empty.Keys(), ShouldBeEmpty)
+			So(empty.Items(), ShouldBeEmpty)
+			So(maps.Collect(empty.All()), ShouldBeEmpty)
+		})
+
+		Convey("Given a cache, items are iterated from most to least recently used", func() {
+			So(cache.Keys(), ShouldResemble, []string{"b", "d", "c", "a"})
+			So(cache.Items(), ShouldResemble, []int{1, 3, 2, 0})
+
+			var keys []string
+			for key, item := range cache.All() {
+				keys = append(keys, key)
+				val, _ := cache.Peek(key)
+				So(item, ShouldEqual, val)
+			}
+			So(keys, ShouldResemble, []string{"b", "d", "c", "a"})
+
+			keys = nil
+			for key := range cache.Backward() {
+				keys = append(keys, key)
+			}
+			So(keys, ShouldResemble, []string{"a", "c", "d", "b"})
+		})
+
+		Convey("Given an iteration, it does not promote items or count hits", func() {
+			for range cache.All() {
+			}
+			So(cache.Keys(), ShouldResemble, []string{"b", "d", "c", "a"})
+			So(cache.Stats().Hits, ShouldEqual, 1)
+		})
+
+		Convey("Given an early break, iteration stops", func() {
+			n := 0
+			for range cache.All() {
+				n++
+				break
+			}
+			So(n, ShouldEqual, 1)
+		})
+
+		Convey("Given expired items, they are skipped", func() {
+			So(cache.PutWithTTL("e", 4, time.Second), ShouldBeNil)
+			So(cache.Keys(), ShouldHaveLength, 5)
+			clock.Advance(time.Second)
+			So(cache.Keys(), ShouldResemble, []string{"b", "d", "c", "a"})
+		})
+
+		Convey("Given modifications during iteration, the iteration sees its starting snapshot", func() {
+			var keys []string
+			for key := range cache.All() {
+				keys = append(keys, key)
+				if key == "b" {
+					So(cache.Remove("c"), ShouldBeNil)
+					So(cache.Put("z", 26), ShouldBeNil)
+				}
+			}
+			So(keys, ShouldResemble, []string{"b", "d", "c", "a"})
+			So(cache.Keys(), ShouldResemble, []string{"z", "b", "d", "a"})
+		})
+	})
+}
@@ -0,0 +1,98 @@
+package lru_cache
+
+import "math"
+
+// lfuPolicy buckets keys by access frequency, such that the least-frequently-used
+// key is the tail of the lowest bucket. Operations are O(1), except when the
+// lowest bucket must be found again after a Remove.
+type lfuPolicy[K comparable] struct {
+	// Nodes hold their frequency as their item.
+	nodes   map[K]*node[K, int]
+	buckets map[int]*doublyLinkedList[K, int]
+	minFreq int
+}
+
+func newLFUPolicy[K comparable]() *lfuPolicy[K] {
+	return &lfuPolicy[K]{
+		nodes:   make(map[K]*node[K, int]),
+		buckets: make(map[int]*doublyLinkedList[K, int]),
+	}
+}
+
+func (p *lfuPolicy[K]) Add(key K) {
+	n := &node[K, int]{key: key, item: 1}
+	p.nodes[key] = n
+	p.bucket(1).Prepend(n)
+	p.minFreq = 1
+}
+
+// Resize is a no-op, since LFU does not depend on the capacity.
+func (p *lfuPolicy[K]) Resize(capacity int) {}
+
+func (p *lfuPolicy[K]) compact() {
+	p.nodes = compactMap(p.nodes)
+	p.buckets = compactMap(p.buckets)
+}
+
+func (p *lfuPolicy[K]) Access(key K) {
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	if p.unbucket(n) && p.minFreq == n.item {
+		p.minFreq++
+	}
+	n.item++
+	p.bucket(n.item).Prepend(n)
+}
+
+func (p *lfuPolicy[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok {
+		// minFreq may now be stale, which Evict corrects.
+		p.unbucket(n)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *lfuPolicy[K]) Evict() (key K, ok bool) {
+	if len(p.buckets) == 0 {
+		return
+	}
+
+	b, ok := p.buckets[p.minFreq]
+	if !ok {
+		p.minFreq = math.MaxInt
+		for freq := range p.buckets {
+			p.minFreq = min(p.minFreq, freq)
+		}
+		b = p.buckets[p.minFreq]
+	}
+
+	victim := b.tail
+	p.unbucket(victim)
+	delete(p.nodes, victim.key)
+
+	return victim.key, true
+}
+
+// bucket returns the list of keys with the passed frequency, creating it if needed.
+func (p *lfuPolicy[K]) bucket(freq int) *doublyLinkedList[K, int] {
+	b, ok := p.buckets[freq]
+	if !ok {
+		b = newDoublyLinkedList[K, int]()
+		p.buckets[freq] = b
+	}
+	return b
+}
+
+// unbucket removes n from its bucket, and returns true if the bucket was emptied and deleted.
+func (p *lfuPolicy[K]) unbucket(n *node[K, int]) bool {
+	b := p.buckets[n.item]
+	_ = b.Remove(n)
+	if b.count > 0 {
+		return false
+	}
+	delete(p.buckets, n.item)
+	return true
+}
@@ -0,0 +1,94 @@
+package lru_cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// flight is a loader call in progress, whose result is shared by all of its waiters.
+type flight[V any] struct {
+	// done is closed once item and err are set.
+	done chan struct{}
+	item V
+	err  error
+}
+
+// negativeEntry is a cached loader error.
+type negativeEntry struct {
+	err     error
+	expires time.Time
+}
+
+// GetOrLoad returns the item stored under key, or calls loader to load it if it
+// is missing, caching and returning the result. Concurrent calls for the same
+// missing key share a single loader call and its result or error. Each caller
+// stops waiting when its own ctx is done, returning ctx.Err(), but the loader
+// itself runs to completion with ctx's values and without its cancellation,
+// such that its result is still cached for other callers.
+// Loader errors are cached per WithNegativeTTL. Loaded items are not saved
+// to the cache's store.
+func (cache *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (item V, err error) {
+	if item, ok := cache.get(key); ok {
+		return item, nil
+	}
+
+	cache.loadMu.Lock()
+	if negative, ok := cache.negatives[key]; ok {
+		if cache.clock.Now().Before(negative.expires) {
+			cache.loadMu.Unlock()
+			return item, negative.err
+		}
+		delete(cache.negatives, key)
+	}
+
+	f, ok := cache.flights[key]
+	if !ok {
+		f = &flight[V]{
+			done: make(chan struct{}),
+		}
+		cache.flights[key] = f
+		go cache.load(context.WithoutCancel(ctx), key, loader, f)
+	}
+	cache.loadMu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.item, f.err
+	case <-ctx.Done():
+		return item, ctx.Err()
+	}
+}
+
+// load calls the loader and completes the flight with its result.
+func (cache *Cache[K, V]) load(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error), f *flight[V]) {
+	defer close(f.done)
+
+	// A panicking loader would otherwise leave its waiters blocked forever.
+	defer func() {
+		if r := recover(); r != nil {
+			f.err = fmt.Errorf("lru_cache: loader panicked: %v", r)
+			cache.completeFlight(key, f)
+		}
+	}()
+
+	f.item, f.err = loader(ctx, key)
+	if f.err == nil {
+		f.item = cache.addLoaded(key, f.item)
+	}
+	cache.completeFlight(key, f)
+}
+
+// completeFlight removes the finished flight, caching its error per the negative TTL.
+func (cache *Cache[K, V]) completeFlight(key K, f *flight[V]) {
+	cache.loadMu.Lock()
+	defer cache.loadMu.Unlock()
+
+	delete(cache.flights, key)
+	if f.err != nil && cache.negativeTTL > 0 {
+		cache.negatives[key] = negativeEntry{
+			err:     f.err,
+			expires: cache.clock.Now().Add(cache.negativeTTL),
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package lru_cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errBackend error = errors.New("backend failure")
+
+func TestGetOrLoad(t *testing.T) {
+	Convey("GetOrLoad tests", t, func() {
+		clock := newFakeClock()
+		cache, err := New[string, int](10, WithClock(clock), WithNegativeTTL(time.Minute))
+		So(err, ShouldBeNil)
+		ctx := context.Background()
+
+		Convey("Given a cached item, the loader is not called", func() {
+			So(cache.Put("abc", 1), ShouldBeNil)
+			val, err := cache.GetOrLoad(ctx, "abc", func(ctx context.Context, key string) (int, error) {
+				panic("unexpected load")
+			})
+			So(err, ShouldBeNil)
+			So(val, ShouldEqual, 1)
+		})
+
+		Convey("Given concurrent misses on one key, the loader is called once", func() {
+			var calls int32
+			release := make(chan struct{})
+			loader := func(ctx context.Context, key string) (int, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 42, nil
+			}
+
+			waiters := 10
+			results := make(chan int, waiters)
+			wg := sync.WaitGroup{}
+			for i := 0; i < waiters; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					val, err := cache.GetOrLoad(ctx, "abc", loader)
+					if err == nil {
+						results <- val
+					}
+				}()
+			}
+
+			// Wait for every waiter to join the flight before releasing the loader.
+			for {
+				cache.loadMu.Lock()
+				f := cache.flights["abc"]
+				cache.loadMu.Unlock()
+				if f != nil && atomic.LoadInt32(&calls) == 1 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+			close(results)
+
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			for val := range results {
+				So(val, ShouldEqual, 42)
+			}
+			val, ok := cache.Get("abc")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 42)
+		})
+
+		Convey("Given a canceled waiter, it returns early while the load completes for others", func() {
+			release := make(chan struct{})
+			loaderCtx := make(chan context.Context, 1)
+			loader := func(ctx context.Context, key string) (int, error) {
+				loaderCtx <- ctx
+				<-release
+				return 7, nil
+			}
+
+			waitCtx, cancel := context.WithCancel(ctx)
+			errs := make(chan error, 1)
+			go func() {
+				_, err := cache.GetOrLoad(waitCtx, "abc", loader)
+				errs <- err
+			}()
+
+			lctx := <-loaderCtx
+			cancel()
+			So(<-errs, ShouldEqual, context.Canceled)
+			// The loader is unaffected by its first caller's cancellation.
+			So(lctx.Err(), ShouldBeNil)
+
+			done := make(chan int)
+			go func() {
+				val, _ := cache.GetOrLoad(ctx, "abc", loader)
+				done <- val
+			}()
+			close(release)
+			So(<-done, ShouldEqual, 7)
+		})
+
+		Convey("Given a loader error, it is cached for the negative TTL", func() {
+			var calls int32
+			loader := func(ctx context.Context, key string) (int, error) {
+				atomic.AddInt32(&calls, 1)
+				return 0, errBackend
+			}
+
+			_, err := cache.GetOrLoad(ctx, "abc", loader)
+			So(err, ShouldEqual, errBackend)
+			_, err = cache.GetOrLoad(ctx, "abc", loader)
+			So(err, ShouldEqual, errBackend)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+
+			clock.Advance(time.Minute)
+			_, err = cache.GetOrLoad(ctx, "abc", loader)
+			So(err, ShouldEqual, errBackend)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 2)
+
+			clock.Advance(time.Minute)
+			So(cache.DeleteExpired(), ShouldEqual, 0)
+			So(cache.negatives, ShouldBeEmpty)
+		})
+
+		Convey("Given no negative TTL, loader errors are not cached", func() {
+			cache, err := New[string, int](10)
+			So(err, ShouldBeNil)
+
+			var calls int32
+			loader := func(ctx context.Context, key string) (int, error) {
+				atomic.AddInt32(&calls, 1)
+				return 0, errBackend
+			}
+			for i := 0; i < 2; i++ {
+				_, err = cache.GetOrLoad(ctx, "abc", loader)
+				So(err, ShouldEqual, errBackend)
+			}
+			So(atomic.LoadInt32(&calls), ShouldEqual, 2)
+		})
+
+		Convey("Given a panicking loader, waiters receive an error", func() {
+			_, err := cache.GetOrLoad(ctx, "abc", func(ctx context.Context, key string) (int, error) {
+				panic("boom")
+			})
+			So(err, ShouldBeError, "lru_cache: loader panicked: boom")
+		})
+	})
+}
@@ -0,0 +1,378 @@
+package memcached
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lru_cache"
+)
+
+const (
+	// maxKeyLength is the longest key memcached accepts.
+	maxKeyLength = 250
+	// maxItemSize is the largest data block accepted by storage commands.
+	maxItemSize = 1 << 20
+	// maxRelativeExptime is the largest expiration time, in seconds, which is
+	// relative to the current time; larger times are Unix timestamps.
+	maxRelativeExptime = 60 * 60 * 24 * 30
+)
+
+var errLineTooLong error = errors.New("memcached: line too long")
+
+// readLine returns the next command line, without its line ending.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", errLineTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// validKey returns true if key is short enough, and free of spaces and control characters.
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// execute runs the command line, reading any data block from r and writing
+// the reply to w. It returns false if the connection should be closed.
+func (s *Server) execute(line string, r *bufio.Reader, w *bufio.Writer) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		_, _ = w.WriteString("ERROR\r\n")
+		return true
+	}
+
+	switch fields[0] {
+	case "get", "gets":
+		s.get(fields[1:], fields[0] == "gets", w)
+	case "set", "add", "replace", "cas":
+		return s.storage(fields, r, w)
+	case "delete":
+		s.delete(fields[1:], w)
+	case "incr", "decr":
+		s.incr(fields[1:], fields[0] == "incr", w)
+	case "stats":
+		s.stats(w)
+	case "version":
+		_, _ = w.WriteString("VERSION " + Version + "\r\n")
+	case "quit":
+		return false
+	default:
+		_, _ = w.WriteString("ERROR\r\n")
+	}
+
+	return true
+}
+
+// noreply strips a trailing noreply argument, and returns whether it was present.
+func noreply(args []string) ([]string, bool) {
+	if n := len(args); n > 0 && args[n-1] == "noreply" {
+		return args[:n-1], true
+	}
+	return args, false
+}
+
+// reply writes msg unless quiet.
+func reply(w *bufio.Writer, msg string, quiet bool) {
+	if !quiet {
+		_, _ = w.WriteString(msg + "\r\n")
+	}
+}
+
+// get writes the items stored under keys, with their CAS versions if withCAS.
+func (s *Server) get(keys []string, withCAS bool, w *bufio.Writer) {
+	if len(keys) == 0 {
+		_, _ = w.WriteString("ERROR\r\n")
+		return
+	}
+
+	for _, key := range keys {
+		if !validKey(key) {
+			_, _ = w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+	}
+
+	for _, key := range keys {
+		item, ok := s.cache.Get(key)
+		if !ok {
+			continue
+		}
+
+		if withCAS {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, item.Flags, len(item.Data), item.CAS)
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, item.Flags, len(item.Data))
+		}
+		_, _ = w.Write(item.Data)
+		_, _ = w.WriteString("\r\n")
+	}
+	_, _ = w.WriteString("END\r\n")
+}
+
+// storage parses a storage command, reads its data block and stores it.
+// It returns false if the data block was malformed.
+//
+//	<command> <key> <flags> <exptime> <bytes> [noreply]
+//	cas <key> <flags> <exptime> <bytes> <cas unique> [noreply]
+func (s *Server) storage(fields []string, r *bufio.Reader, w *bufio.Writer) bool {
+	cmd := fields[0]
+	args, quiet := noreply(fields[1:])
+
+	want := 4
+	if cmd == "cas" {
+		want = 5
+	}
+	if len(args) != want || !validKey(args[0]) {
+		return rejectStorage(args, r, w)
+	}
+
+	key := args[0]
+	flags, err1 := strconv.ParseUint(args[1], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[2], 10, 64)
+	size, err3 := strconv.Atoi(args[3])
+	var casUnique uint64
+	var err4 error
+	if cmd == "cas" {
+		casUnique, err4 = strconv.ParseUint(args[4], 10, 64)
+	}
+	if err := errors.Join(err1, err2, err3, err4); err != nil || size < 0 {
+		return rejectStorage(args, r, w)
+	}
+
+	// The data block is read in full even if it is too large to store,
+	// so that it is not mistaken for commands.
+	if size > maxItemSize {
+		if _, err := io.CopyN(io.Discard, r, int64(size)+2); err != nil {
+			return false
+		}
+		reply(w, "SERVER_ERROR object too large for cache", quiet)
+		return true
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return false
+	}
+	if string(data[size:]) != "\r\n" {
+		_, _ = w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+
+	reply(w, s.store(cmd, key, uint32(flags), exptime, data[:size], casUnique), quiet)
+	return true
+}
+
+// rejectStorage replies to a malformed storage command, and discards its data
+// block if its <bytes> argument parses, so that the block is not mistaken for
+// commands. It returns false if the data block could not be read.
+func rejectStorage(args []string, r *bufio.Reader, w *bufio.Writer) bool {
+	_, _ = w.WriteString("CLIENT_ERROR bad command line format\r\n")
+	if len(args) < 4 {
+		return true
+	}
+	if size, err := strconv.Atoi(args[3]); err == nil && size >= 0 {
+		if _, err := io.CopyN(io.Discard, r, int64(size)+2); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// store applies a storage command, and returns its reply.
+func (s *Server) store(cmd, key string, flags uint32, exptime int64, data []byte, casUnique uint64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, found := s.cache.Peek(key)
+	switch cmd {
+	case "add":
+		if found {
+			return "NOT_STORED"
+		}
+	case "replace":
+		if !found {
+			return "NOT_STORED"
+		}
+	case "cas":
+		if !found {
+			return "NOT_FOUND"
+		}
+		if existing.CAS != casUnique {
+			return "EXISTS"
+		}
+	}
+
+	expires, expired := s.expiry(exptime)
+	if expired {
+		// The item is stored, and immediately expires.
+		_ = s.cache.Remove(key)
+		return "STORED"
+	}
+
+	return s.set(key, &Item{
+		Flags:   flags,
+		Data:    data,
+		expires: expires,
+	})
+}
+
+// set caches item under key with a new CAS version, and returns the reply
+// to a storage command. The caller must hold s.mu.
+func (s *Server) set(key string, item *Item) string {
+	item.CAS = s.lastCAS.Add(1)
+
+	var ttl time.Duration
+	if !item.expires.IsZero() {
+		if ttl = item.expires.Sub(s.now()); ttl <= 0 {
+			_ = s.cache.Remove(key)
+			return "STORED"
+		}
+	}
+
+	if err := s.cache.SetWithTTL(key, item, ttl); err != nil {
+		var tooLarge *lru_cache.ItemTooLargeError
+		if errors.As(err, &tooLarge) {
+			return "SERVER_ERROR object too large for cache"
+		}
+		return "SERVER_ERROR " + err.Error()
+	}
+
+	return "STORED"
+}
+
+// expiry converts a protocol expiration time to an absolute time, which is
+// zero if the item never expires, and returns true if it has already passed.
+func (s *Server) expiry(exptime int64) (time.Time, bool) {
+	switch {
+	case exptime == 0:
+		return time.Time{}, false
+	case exptime < 0:
+		return time.Time{}, true
+	case exptime <= maxRelativeExptime:
+		return s.now().Add(time.Duration(exptime) * time.Second), false
+	}
+
+	expires := time.Unix(exptime, 0)
+	return expires, !expires.After(s.now())
+}
+
+// delete removes an item.
+//
+//	delete <key> [noreply]
+func (s *Server) delete(args []string, w *bufio.Writer) {
+	args, quiet := noreply(args)
+	if len(args) != 1 || !validKey(args[0]) {
+		_, _ = w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+
+	s.mu.Lock()
+	err := s.cache.Remove(args[0])
+	s.mu.Unlock()
+
+	if err != nil {
+		reply(w, "NOT_FOUND", quiet)
+		return
+	}
+	reply(w, "DELETED", quiet)
+}
+
+// incr adds to, or if !up subtracts from, an item's decimal value. Increments
+// wrap around at 64 bits, and decrements stop at zero.
+//
+//	incr <key> <value> [noreply]
+//	decr <key> <value> [noreply]
+func (s *Server) incr(args []string, up bool, w *bufio.Writer) {
+	args, quiet := noreply(args)
+	if len(args) != 2 || !validKey(args[0]) {
+		_, _ = w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		_, _ = w.WriteString("CLIENT_ERROR invalid numeric delta argument\r\n")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := args[0]
+	item, ok := s.cache.Peek(key)
+	if !ok {
+		reply(w, "NOT_FOUND", quiet)
+		return
+	}
+	value, err := strconv.ParseUint(string(item.Data), 10, 64)
+	if err != nil {
+		_, _ = w.WriteString("CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+		return
+	}
+
+	switch {
+	case up:
+		value += delta
+	case delta > value:
+		value = 0
+	default:
+		value -= delta
+	}
+
+	data := strconv.FormatUint(value, 10)
+	if result := s.set(key, &Item{Flags: item.Flags, Data: []byte(data), expires: item.expires}); result != "STORED" {
+		reply(w, result, quiet)
+		return
+	}
+	reply(w, data, quiet)
+}
+
+// stats writes the server's and cache's statistics, named as memcached's.
+func (s *Server) stats(w *bufio.Writer) {
+	stats := s.cache.Stats()
+	now := s.now()
+
+	s.connsMu.Lock()
+	currConnections, totalConnections := len(s.conns), s.totalConnections
+	s.connsMu.Unlock()
+
+	for _, stat := range []struct {
+		name  string
+		value any
+	}{
+		{"pid", os.Getpid()},
+		{"uptime", int64(now.Sub(s.started).Seconds())},
+		{"time", now.Unix()},
+		{"version", Version},
+		{"curr_connections", currConnections},
+		{"total_connections", totalConnections},
+		{"cmd_get", stats.Hits + stats.Misses},
+		{"cmd_set", stats.Puts},
+		{"get_hits", stats.Hits},
+		{"get_misses", stats.Misses},
+		{"curr_items", stats.Size},
+		{"bytes", stats.Cost},
+		{"limit_maxbytes", stats.MaxCost},
+		{"evictions", stats.Evictions[lru_cache.EvictedCapacity]},
+		{"expired", stats.Evictions[lru_cache.EvictedExpired]},
+	} {
+		fmt.Fprintf(w, "STAT %s %v\r\n", stat.name, stat.value)
+	}
+	_, _ = w.WriteString("END\r\n")
+}
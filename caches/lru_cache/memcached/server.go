@@ -0,0 +1,191 @@
+// Package memcached serves a lru_cache over the memcached text protocol, such
+// that it may stand in for memcached in integration tests. It supports the
+// get, gets, set, add, replace, cas, delete, incr, decr, stats, version and
+// quit commands.
+package memcached
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lru_cache"
+)
+
+// Version is reported by the version and stats commands.
+const Version = "1.6.0-lru_cache"
+
+// ErrServerClosed is returned by Serve once the server is closed.
+var ErrServerClosed error = errors.New("memcached: server closed")
+
+// Item is a cached value, as stored by the protocol's storage commands.
+// Items are immutable once cached; commands replace them instead.
+type Item struct {
+	Flags uint32
+	Data  []byte
+	// CAS is the item's unique version, which changes whenever it is stored.
+	CAS uint64
+	// expires is the zero time if the item never expires; it is kept such
+	// that incr and decr preserve it.
+	expires time.Time
+}
+
+// Cost returns the item's size in bytes, for use with lru_cache.WithCost.
+func Cost(item *Item) int64 {
+	// The overhead approximates the item's other fields and its cache entry.
+	const overhead = 64
+	return int64(len(item.Data)) + overhead
+}
+
+// Server serves a cache of Items to memcached clients.
+type Server struct {
+	cache *lru_cache.Cache[string, *Item]
+	// mu serializes commands that modify the cache, such that those which
+	// read an item before replacing it, e.g. cas, are atomic.
+	mu      sync.Mutex
+	lastCAS atomic.Uint64
+	started time.Time
+	// now is the wall clock, from which expiration times are computed.
+	now func() time.Time
+
+	connsMu          sync.Mutex
+	listeners        map[net.Listener]struct{}
+	conns            map[net.Conn]struct{}
+	totalConnections uint64
+	closed           bool
+	wg               sync.WaitGroup
+}
+
+// NewServer returns a server of the passed cache. The cache should not be
+// modified other than through the server.
+func NewServer(cache *lru_cache.Cache[string, *Item]) *Server {
+	return &Server{
+		cache:     cache,
+		started:   time.Now(),
+		now:       time.Now,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves connections from it, per Serve.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections from l and serves each from its own goroutine, until
+// the server is closed, when it returns ErrServerClosed, or accepting fails.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l, nil) {
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l, nil)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !s.track(nil, conn) {
+			_ = conn.Close()
+			return ErrServerClosed
+		}
+		go func() {
+			defer s.untrack(nil, conn)
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close stops the server's listeners, closes its connections, and waits for
+// their goroutines to exit. It does not close the cache.
+func (s *Server) Close() error {
+	s.connsMu.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.connsMu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// track records a listener or connection, or returns false if the server is closed.
+func (s *Server) track(l net.Listener, conn net.Conn) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	if l != nil {
+		s.listeners[l] = struct{}{}
+	}
+	if conn != nil {
+		s.conns[conn] = struct{}{}
+		s.totalConnections++
+	}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) untrack(l net.Listener, conn net.Conn) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	delete(s.listeners, l)
+	if conn != nil {
+		delete(s.conns, conn)
+		_ = conn.Close()
+	}
+	s.wg.Done()
+}
+
+func (s *Server) isClosed() bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	return s.closed
+}
+
+// serveConn reads and executes commands from conn until it is closed, or
+// the client quits or sends a malformed data block.
+func (s *Server) serveConn(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return
+		}
+
+		if !s.execute(line, r, w) {
+			_ = w.Flush()
+			return
+		}
+
+		// Replies are flushed once pipelined commands have been read.
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package memcached
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"lru_cache"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// client is a minimal memcached text protocol client.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(addr string) *client {
+	conn, err := net.Dial("tcp", addr)
+	So(err, ShouldBeNil)
+	return &client{conn: conn, r: bufio.NewReader(conn)}
+}
+
+// send writes the passed lines, each terminated by \r\n.
+func (c *client) send(lines ...string) {
+	_, err := c.conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+	So(err, ShouldBeNil)
+}
+
+// expect reads a line per want, and checks it.
+func (c *client) expect(want ...string) {
+	for _, w := range want {
+		line, err := c.r.ReadString('\n')
+		So(err, ShouldBeNil)
+		So(line, ShouldEqual, w+"\r\n")
+	}
+}
+
+// gets returns the CAS version of key.
+func (c *client) gets(key string) (cas uint64) {
+	c.send("gets " + key)
+	line, err := c.r.ReadString('\n')
+	So(err, ShouldBeNil)
+	var flags, size int
+	_, err = fmt.Sscanf(line, "VALUE "+key+" %d %d %d\r\n", &flags, &size, &cas)
+	So(err, ShouldBeNil)
+	_, err = c.r.Discard(size + 2)
+	So(err, ShouldBeNil)
+	c.expect("END")
+	return
+}
+
+func TestServer(t *testing.T) {
+	Convey("memcached server tests", t, func() {
+		cache, err := lru_cache.New[string, *Item](100, lru_cache.WithMaxCost(1<<20), lru_cache.WithCost(Cost))
+		So(err, ShouldBeNil)
+		server := NewServer(cache)
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		served := make(chan error, 1)
+		go func() {
+			served <- server.Serve(l)
+		}()
+		defer func() {
+			So(server.Close(), ShouldBeNil)
+			So(<-served, ShouldEqual, ErrServerClosed)
+		}()
+
+		c := dial(l.Addr().String())
+		defer c.conn.Close()
+
+		Convey("Given stored items, get and gets return them", func() {
+			c.send("set a 5 0 3", "abc")
+			c.expect("STORED")
+			c.send("set b 0 0 0", "")
+			c.expect("STORED")
+
+			c.send("get a b missing")
+			c.expect("VALUE a 5 3", "abc", "VALUE b 0 0", "", "END")
+
+			cas := c.gets("a")
+			So(cas, ShouldBeGreaterThan, 0)
+			So(c.gets("b"), ShouldBeGreaterThan, cas)
+		})
+
+		Convey("add only stores new keys, and replace only existing keys", func() {
+			c.send("add a 0 0 1", "1")
+			c.expect("STORED")
+			c.send("add a 0 0 1", "2")
+			c.expect("NOT_STORED")
+			c.send("replace a 0 0 1", "3")
+			c.expect("STORED")
+			c.send("replace z 0 0 1", "4")
+			c.expect("NOT_STORED")
+
+			c.send("get a z")
+			c.expect("VALUE a 0 1", "3", "END")
+		})
+
+		Convey("cas only stores if the item is unchanged", func() {
+			c.send("cas a 0 0 1 1", "x")
+			c.expect("NOT_FOUND")
+
+			c.send("set a 0 0 1", "1")
+			c.expect("STORED")
+			cas := c.gets("a")
+
+			c.send(fmt.Sprintf("cas a 0 0 1 %d", cas), "2")
+			c.expect("STORED")
+			c.send(fmt.Sprintf("cas a 0 0 1 %d", cas), "3")
+			c.expect("EXISTS")
+
+			c.send("get a")
+			c.expect("VALUE a 0 1", "2", "END")
+		})
+
+		Convey("delete removes items", func() {
+			c.send("set a 0 0 1", "1")
+			c.expect("STORED")
+			c.send("delete a")
+			c.expect("DELETED")
+			c.send("delete a")
+			c.expect("NOT_FOUND")
+			c.send("get a")
+			c.expect("END")
+		})
+
+		Convey("incr and decr change decimal values", func() {
+			c.send("incr n 1")
+			c.expect("NOT_FOUND")
+
+			c.send("set n 7 0 2", "10")
+			c.expect("STORED")
+			c.send("incr n 5")
+			c.expect("15")
+			c.send("decr n 20")
+			c.expect("0")
+			c.send("incr n 18446744073709551615", "incr n 2")
+			c.expect("18446744073709551615", "1")
+			c.send("get n")
+			c.expect("VALUE n 7 1", "1", "END")
+
+			c.send("set s 0 0 3", "abc")
+			c.expect("STORED")
+			c.send("incr s 1")
+			c.expect("CLIENT_ERROR cannot increment or decrement non-numeric value")
+			c.send("incr n x")
+			c.expect("CLIENT_ERROR invalid numeric delta argument")
+		})
+
+		Convey("noreply suppresses replies", func() {
+			c.send("set a 0 0 1 noreply", "1", "add a 0 0 1 noreply", "2", "delete z noreply", "get a")
+			c.expect("VALUE a 0 1", "1", "END")
+		})
+
+		Convey("Items with past expiration times are not kept", func() {
+			c.send("set a 0 -1 1", "1")
+			c.expect("STORED")
+			c.send("set b 0 1000000000 1", "1")
+			c.expect("STORED")
+			c.send("get a b")
+			c.expect("END")
+
+			c.send("set c 0 60 1", "1")
+			c.expect("STORED")
+			item, ok := cache.Peek("c")
+			So(ok, ShouldBeTrue)
+			So(item.expires.IsZero(), ShouldBeFalse)
+		})
+
+		Convey("Malformed commands are rejected", func() {
+			c.send("bogus")
+			c.expect("ERROR")
+			c.send("get")
+			c.expect("ERROR")
+			c.send("set a 0 0")
+			c.expect("CLIENT_ERROR bad command line format")
+			c.send("version")
+			c.expect("VERSION " + Version)
+
+			// The data blocks of rejected commands are skipped, when their size is known.
+			c.send("set "+strings.Repeat("k", maxKeyLength+1)+" 0 0 5", "hello", "version")
+			c.expect("CLIENT_ERROR bad command line format", "VERSION "+Version)
+			c.send("set a x 0 5", "hello", "cas a 0 0 5 y", "hello", "get a")
+			c.expect("CLIENT_ERROR bad command line format", "CLIENT_ERROR bad command line format", "END")
+
+			c.send("set a 0 0 1", "toolong")
+			c.expect("CLIENT_ERROR bad data chunk")
+			_, err := c.r.ReadString('\n')
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("stats reports the cache's statistics", func() {
+			c.send("set a 0 0 1", "1", "get a", "get z")
+			c.expect("STORED", "VALUE a 0 1", "1", "END", "END")
+
+			c.send("stats")
+			stats := make(map[string]string)
+			for {
+				line, err := c.r.ReadString('\n')
+				So(err, ShouldBeNil)
+				if line == "END\r\n" {
+					break
+				}
+				fields := strings.Fields(line)
+				So(fields, ShouldHaveLength, 3)
+				So(fields[0], ShouldEqual, "STAT")
+				stats[fields[1]] = fields[2]
+			}
+			So(stats["get_hits"], ShouldEqual, "1")
+			So(stats["get_misses"], ShouldEqual, "1")
+			So(stats["cmd_set"], ShouldEqual, "1")
+			So(stats["curr_items"], ShouldEqual, "1")
+			So(stats["curr_connections"], ShouldEqual, "1")
+			So(stats["limit_maxbytes"], ShouldEqual, "1048576")
+		})
+
+		Convey("Concurrent clients increment atomically", func() {
+			c.send("set n 0 0 1", "0")
+			c.expect("STORED")
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					conn, err := net.Dial("tcp", l.Addr().String())
+					if err != nil {
+						return
+					}
+					defer conn.Close()
+					r := bufio.NewReader(conn)
+					for j := 0; j < 50; j++ {
+						if _, err := conn.Write([]byte("incr n 1\r\n")); err != nil {
+							return
+						}
+						if _, err := r.ReadString('\n'); err != nil {
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			c.send("get n")
+			c.expect("VALUE n 0 3", "200", "END")
+		})
+	})
+}
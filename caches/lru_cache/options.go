@@ -0,0 +1,156 @@
+package lru_cache
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures optional cache behavior when passed to New.
+type Option func(*options)
+
+type options struct {
+	defaultTTL      time.Duration
+	clock           Clock
+	janitorInterval time.Duration
+	// store is a Store[K, V], which is only type-checked by New.
+	store     any
+	storeMode StoreMode
+	policy    Policy
+	maxCost   int64
+	// costFunc is a func(V) int64, which is only type-checked by New.
+	costFunc    any
+	negativeTTL time.Duration
+	// codec is a Codec[V], which is only type-checked by New.
+	codec          any
+	snapshotFormat SnapshotFormat
+	softTTL        time.Duration
+	// refresher is a func(context.Context, K) (V, error), which is only type-checked by New.
+	refresher any
+}
+
+func defaultOptions() options {
+	return options{
+		clock: realClock{},
+	}
+}
+
+func (o *options) validate() error {
+	if o.defaultTTL < 0 || o.janitorInterval < 0 || o.clock == nil || o.maxCost < 0 || o.negativeTTL < 0 {
+		return ErrInvalidOption
+	}
+	if o.softTTL < 0 || (o.softTTL > 0) != (o.refresher != nil) {
+		return ErrInvalidOption
+	}
+	if o.store != nil && !o.storeMode.valid() {
+		return ErrInvalidOption
+	}
+	if o.policy < LRU || o.policy > TinyLFU {
+		return ErrInvalidOption
+	}
+	if o.snapshotFormat < GobFormat || o.snapshotFormat > JSONFormat {
+		return ErrInvalidOption
+	}
+	return nil
+}
+
+// WithDefaultTTL sets the time-to-live of items added by Put.
+// A zero ttl, the default, means items never expire.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.defaultTTL = ttl
+	}
+}
+
+// WithClock replaces the wall clock used for expiration, e.g. for testing.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithJanitor starts a goroutine that removes expired items every interval,
+// until the cache is closed. Without a janitor, expired items are only
+// removed lazily when they are accessed or evicted.
+func WithJanitor(interval time.Duration) Option {
+	return func(o *options) {
+		o.janitorInterval = interval
+	}
+}
+
+// WithStore backs the cache with the passed store, per mode.
+// New returns ErrInvalidOption if the store's key and value types
+// differ from the cache's, or if mode is invalid.
+func WithStore[K comparable, V any](store Store[K, V], mode StoreMode) Option {
+	return func(o *options) {
+		o.store = store
+		o.storeMode = mode
+	}
+}
+
+// WithPolicy selects the cache's eviction policy; the default is LRU.
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithMaxCost evicts items while their total cost exceeds maxCost, in addition
+// to the cache's capacity. Items cost one unless WithCost is also passed.
+func WithMaxCost(maxCost int64) Option {
+	return func(o *options) {
+		o.maxCost = maxCost
+	}
+}
+
+// WithCost sets the function returning the cost of an item, e.g. its size in bytes,
+// for use with WithMaxCost. The function is called with the cache locked, so it must
+// not call into the cache, and return a positive cost; items for which it does not
+// are rejected with ErrInvalidCost. New returns ErrInvalidOption if V differs from
+// the cache's.
+func WithCost[V any](cost func(item V) int64) Option {
+	return func(o *options) {
+		o.costFunc = cost
+	}
+}
+
+// WithNegativeTTL caches GetOrLoad's loader errors for ttl, during which further
+// calls for the same key return the error without calling the loader.
+// A zero ttl, the default, means errors are not cached.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithSnapshotFormat selects the format of snapshots written by SaveTo and
+// read by LoadFrom; the default is GobFormat.
+func WithSnapshotFormat(format SnapshotFormat) Option {
+	return func(o *options) {
+		o.snapshotFormat = format
+	}
+}
+
+// WithCodec sets the Codec with which SaveTo and LoadFrom marshal items.
+// By default items are marshaled in the snapshot's format.
+// New returns ErrInvalidOption if V differs from the cache's.
+func WithCodec[V any](codec Codec[V]) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithRefresh serves items stale-while-revalidate: once an item is softTTL old,
+// Get still returns it, but also starts a refresh of it by loader in the
+// background. Until the refreshed item replaces it, the stale item is served
+// without further refreshes. Once an item reaches its TTL it expires as usual.
+// New returns ErrInvalidOption if softTTL is not positive, or if the loader's
+// key and value types differ from the cache's.
+func WithRefresh[K comparable, V any](softTTL time.Duration, loader func(ctx context.Context, key K) (V, error)) Option {
+	return func(o *options) {
+		o.softTTL = softTTL
+		o.refresher = nil
+		if loader != nil {
+			o.refresher = loader
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"lru_cache"
+)
+
+// Getter loads the value of a key from its source, e.g. a database, when the
+// key is missing from the cache of the peer that owns it.
+type Getter func(ctx context.Context, key string) ([]byte, error)
+
+// Group is a named cache, whose keys are spread across the peers of a pool.
+// Every peer must create the group with the same name and an equivalent getter.
+type Group struct {
+	name   string
+	getter Getter
+	pool   *HTTPPool
+	// main caches the keys this peer owns, and hot the keys it fetched from their owners.
+	main  *lru_cache.Cache[string, []byte]
+	hot   *lru_cache.Cache[string, []byte]
+	stats groupCounters
+}
+
+type groupCounters struct {
+	gets           atomic.Uint64
+	loads          atomic.Uint64
+	peerLoads      atomic.Uint64
+	peerErrors     atomic.Uint64
+	serverRequests atomic.Uint64
+}
+
+// GroupStats is a snapshot of a group's counters, and its caches' Stats.
+type GroupStats struct {
+	// Gets counts calls to Get, and ServerRequests requests from other peers.
+	Gets           uint64
+	ServerRequests uint64
+	// Loads counts calls to the getter, and PeerLoads successful fetches from other peers.
+	Loads     uint64
+	PeerLoads uint64
+	// PeerErrors counts failed fetches from other peers, after which the key was loaded locally.
+	PeerErrors uint64
+	Main       lru_cache.Stats
+	Hot        lru_cache.Stats
+}
+
+// ErrDuplicateGroup is returned when a pool already has a group of the same name.
+var ErrDuplicateGroup error = errors.New("duplicate group name")
+
+// Name returns the group's name.
+func (group *Group) Name() string {
+	return group.name
+}
+
+// Get returns the value of key. If this peer owns the key, it is loaded by
+// the getter and cached; otherwise it is fetched from its owner, and cached in
+// the hot cache. If the owner cannot be reached, the key is loaded locally instead,
+// but if the owner's getter fails, its error is returned.
+// Concurrent calls for a key share a single load or fetch, per Cache.GetOrLoad.
+// The returned slice is shared, and must not be modified.
+func (group *Group) Get(ctx context.Context, key string) ([]byte, error) {
+	group.stats.gets.Add(1)
+
+	owner, remote := group.pool.pick(key)
+	if !remote {
+		return group.main.GetOrLoad(ctx, key, group.load)
+	}
+
+	// This peer may have owned the key before the pool's peers changed.
+	// Keys loaded locally when their owner cannot be reached are kept in
+	// the hot cache, so that they age out once it can be reached again.
+	if value, ok := group.main.Peek(key); ok {
+		return value, nil
+	}
+	return group.hot.GetOrLoad(ctx, key, func(ctx context.Context, key string) ([]byte, error) {
+		value, err := group.pool.fetch(ctx, owner, group.name, key)
+		if err == nil {
+			group.stats.peerLoads.Add(1)
+			return value, nil
+		}
+		var getterErr *getterError
+		if errors.As(err, &getterErr) {
+			return nil, err
+		}
+
+		group.stats.peerErrors.Add(1)
+		return group.load(ctx, key)
+	})
+}
+
+// getLocally returns the value of key from the main cache, loading it if it is
+// missing. It serves other peers' fetches, which must not be forwarded again
+// even if the peers disagree on the key's owner.
+func (group *Group) getLocally(ctx context.Context, key string) ([]byte, error) {
+	group.stats.serverRequests.Add(1)
+	return group.main.GetOrLoad(ctx, key, group.load)
+}
+
+func (group *Group) load(ctx context.Context, key string) ([]byte, error) {
+	group.stats.loads.Add(1)
+	return group.getter(ctx, key)
+}
+
+// Stats returns a snapshot of the group's counters.
+func (group *Group) Stats() GroupStats {
+	return GroupStats{
+		Gets:           group.stats.gets.Load(),
+		ServerRequests: group.stats.serverRequests.Load(),
+		Loads:          group.stats.loads.Load(),
+		PeerLoads:      group.stats.peerLoads.Load(),
+		PeerErrors:     group.stats.peerErrors.Load(),
+		Main:           group.main.Stats(),
+		Hot:            group.hot.Stats(),
+	}
+}
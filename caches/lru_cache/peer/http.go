@@ -0,0 +1,248 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"lru_cache"
+)
+
+const (
+	// DefaultBasePath is the path prefix under which peers serve their groups.
+	DefaultBasePath = "/_lru_cache/"
+	// DefaultReplicas is the number of virtual nodes per peer on the ring.
+	DefaultReplicas = 50
+
+	// getterErrorHeader marks the responses of peers whose getter failed to load
+	// the requested key, as opposed to those that failed to serve the request.
+	getterErrorHeader = "X-Getter-Error"
+)
+
+// getterError is the error of another peer's getter, as returned by fetch.
+type getterError struct {
+	peer    string
+	message string
+}
+
+func (e *getterError) Error() string {
+	return fmt.Sprintf("peer %s: %s", e.peer, e.message)
+}
+
+// HTTPPool is the set of peers sharing a pool's groups, and the http.Handler
+// through which this peer serves them. Each peer is identified by its base
+// URL, e.g. "http://10.0.0.1:8080", and keys are fetched from their owner with
+// GET requests for {peer}{basePath}{group}/{key}, whose path elements are escaped.
+type HTTPPool struct {
+	self     string
+	basePath string
+	replicas int
+	client   *http.Client
+
+	mu     sync.RWMutex
+	ring   *Ring
+	groups map[string]*Group
+}
+
+// PoolOption configures optional pool behavior when passed to NewHTTPPool.
+type PoolOption func(*HTTPPool)
+
+// WithBasePath sets the path prefix under which peers serve their groups,
+// which must be the same for every peer; the default is DefaultBasePath.
+func WithBasePath(basePath string) PoolOption {
+	return func(pool *HTTPPool) {
+		pool.basePath = basePath
+	}
+}
+
+// WithReplicas sets the number of virtual nodes per peer on the ring, which
+// must be the same for every peer; the default is DefaultReplicas.
+func WithReplicas(replicas int) PoolOption {
+	return func(pool *HTTPPool) {
+		pool.replicas = replicas
+	}
+}
+
+// WithClient sets the client with which keys are fetched from other peers,
+// e.g. to set a timeout; the default is http.DefaultClient.
+func WithClient(client *http.Client) PoolOption {
+	return func(pool *HTTPPool) {
+		pool.client = client
+	}
+}
+
+// NewHTTPPool returns a pool for the peer whose base URL is self, which owns
+// every key until the pool's peers are set.
+func NewHTTPPool(self string, opts ...PoolOption) *HTTPPool {
+	pool := &HTTPPool{
+		self:     self,
+		basePath: DefaultBasePath,
+		replicas: DefaultReplicas,
+		client:   http.DefaultClient,
+		groups:   make(map[string]*Group),
+	}
+	for _, opt := range opts {
+		opt(pool)
+	}
+	if !strings.HasSuffix(pool.basePath, "/") {
+		pool.basePath += "/"
+	}
+
+	return pool
+}
+
+// Set replaces the pool's peers with the passed base URLs, which should include
+// this peer's own, and be the same for every peer.
+func (pool *HTTPPool) Set(peers ...string) {
+	ring := NewRing(pool.replicas, nil)
+	ring.Add(peers...)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.ring = ring
+}
+
+// NewGroup creates a group of the passed name in the pool, whose main cache holds
+// capacity keys, and whose hot cache an eighth as many. The options apply to both
+// caches, e.g. to expire keys. It returns ErrDuplicateGroup if the name is taken.
+func (pool *HTTPPool) NewGroup(name string, capacity int, getter Getter, opts ...lru_cache.Option) (*Group, error) {
+	// The name is checked before the caches are built, and the lock held until
+	// the group is added, so that no caches are built for a name that is taken.
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if _, ok := pool.groups[name]; ok {
+		return nil, ErrDuplicateGroup
+	}
+
+	main, err := lru_cache.New[string, []byte](capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+	hot, err := lru_cache.New[string, []byte](max(1, capacity/8), opts...)
+	if err != nil {
+		_ = main.Close()
+		return nil, err
+	}
+
+	group := &Group{
+		name:   name,
+		getter: getter,
+		pool:   pool,
+		main:   main,
+		hot:    hot,
+	}
+	pool.groups[name] = group
+
+	return group, nil
+}
+
+// Group returns the pool's group of the passed name, or nil.
+func (pool *HTTPPool) Group(name string) *Group {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.groups[name]
+}
+
+// pick returns the peer that owns key, and false if it is this peer.
+func (pool *HTTPPool) pick(key string) (string, bool) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if pool.ring == nil {
+		return "", false
+	}
+	owner, ok := pool.ring.Get(key)
+	if !ok || owner == pool.self {
+		return "", false
+	}
+	return owner, true
+}
+
+// fetch requests the value of the group's key from the passed peer. If the
+// peer's getter failed to load it, fetch returns a *getterError.
+func (pool *HTTPPool) fetch(ctx context.Context, peer, group, key string) ([]byte, error) {
+	u := peer + pool.basePath + url.PathEscape(group) + "/" + url.PathEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := pool.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(string(value))
+		if resp.Header.Get(getterErrorHeader) != "" {
+			return nil, &getterError{peer: peer, message: message}
+		}
+		return nil, fmt.Errorf("peer %s: %s: %s", peer, resp.Status, message)
+	}
+
+	return value, nil
+}
+
+// ServeHTTP serves other peers' requests for the keys of this pool's groups,
+// which are loaded locally if they are missing.
+func (pool *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.EscapedPath(), pool.basePath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	escapedName, escapedKey, ok := strings.Cut(rest, "/")
+	if !ok {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	name, err := url.PathUnescape(escapedName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := url.PathUnescape(escapedKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group := pool.Group(name)
+	if group == nil {
+		http.Error(w, "no such group: "+name, http.StatusNotFound)
+		return
+	}
+
+	value, err := group.getLocally(r.Context(), key)
+	if err != nil {
+		// Errors of the request's own context are not the getter's, but the
+		// requesting peer's, e.g. a timeout, which is a transport failure.
+		cancelled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+		if !cancelled || r.Context().Err() == nil {
+			w.Header().Set(getterErrorHeader, "1")
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(value)
+}
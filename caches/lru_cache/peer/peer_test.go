@@ -0,0 +1,190 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"lru_cache"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errNotFound error = errors.New("not found")
+
+// source is a Getter's backing data, which counts loads per key.
+type source struct {
+	mu    sync.Mutex
+	loads map[string]int
+}
+
+func (s *source) get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loads[key]++
+	if key == "missing" {
+		return nil, errNotFound
+	}
+	return []byte("value of " + key), nil
+}
+
+// startPeers serves n pools of one group from httptest servers, and sets each
+// pool's peers to all of them.
+func startPeers(n int, src *source) (pools []*HTTPPool, groups []*Group, servers []*httptest.Server) {
+	urls := make([]string, n)
+	for i := range urls {
+		// The listener is created first, so that the pool knows its own URL.
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		urls[i] = "http://" + l.Addr().String()
+
+		pool := NewHTTPPool(urls[i])
+		group, err := pool.NewGroup("things", 400, src.get)
+		So(err, ShouldBeNil)
+
+		server := httptest.NewUnstartedServer(pool)
+		server.Listener.Close()
+		server.Listener = l
+		server.Start()
+
+		pools = append(pools, pool)
+		groups = append(groups, group)
+		servers = append(servers, server)
+	}
+
+	for _, pool := range pools {
+		pool.Set(urls...)
+	}
+	return
+}
+
+func TestHTTPPool(t *testing.T) {
+	Convey("HTTP pool tests", t, func() {
+		src := &source{loads: make(map[string]int)}
+		pools, groups, servers := startPeers(3, src)
+		defer func() {
+			for _, server := range servers {
+				server.Close()
+			}
+		}()
+		ctx := context.Background()
+
+		Convey("Given keys requested from every peer, each is loaded once by its owner", func() {
+			for i := 0; i < 30; i++ {
+				key := fmt.Sprint("key", i)
+				for _, group := range groups {
+					value, err := group.Get(ctx, key)
+					So(err, ShouldBeNil)
+					So(string(value), ShouldEqual, "value of "+key)
+				}
+				So(src.loads[key], ShouldEqual, 1)
+			}
+
+			var loads, peerLoads, serverRequests uint64
+			for _, group := range groups {
+				stats := group.Stats()
+				loads += stats.Loads
+				peerLoads += stats.PeerLoads
+				serverRequests += stats.ServerRequests
+				So(stats.PeerErrors, ShouldEqual, 0)
+			}
+			So(loads, ShouldEqual, 30)
+			So(peerLoads, ShouldEqual, 60)
+			So(serverRequests, ShouldEqual, 60)
+
+			// Repeated gets are served from the hot caches.
+			for i := 0; i < 30; i++ {
+				for _, group := range groups {
+					_, err := group.Get(ctx, fmt.Sprint("key", i))
+					So(err, ShouldBeNil)
+				}
+			}
+			serverRequests = 0
+			for _, group := range groups {
+				serverRequests += group.Stats().ServerRequests
+			}
+			So(serverRequests, ShouldEqual, 60)
+		})
+
+		Convey("Given a getter error, it is returned by the owner to other peers", func() {
+			for _, group := range groups {
+				_, err := group.Get(ctx, "missing")
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, errNotFound.Error())
+				So(group.Stats().PeerErrors, ShouldEqual, 0)
+			}
+			// The key is only loaded by its owner, once for each peer's Get.
+			So(src.loads["missing"], ShouldEqual, len(groups))
+		})
+
+		Convey("Given a cancelled request, its error is not the getter's", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+			req := httptest.NewRequest(http.MethodGet, DefaultBasePath+"things/key0", nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+			pools[0].ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(w.Header().Get(getterErrorHeader), ShouldBeEmpty)
+
+			req = httptest.NewRequest(http.MethodGet, DefaultBasePath+"things/missing", nil)
+			w = httptest.NewRecorder()
+			pools[0].ServeHTTP(w, req)
+			So(w.Header().Get(getterErrorHeader), ShouldNotBeEmpty)
+		})
+
+		Convey("Given an unreachable owner, the key is loaded locally", func() {
+			owner, _ := pools[0].ring.Get("key0")
+			for _, server := range servers {
+				if server.URL == owner {
+					server.Close()
+				}
+			}
+
+			for i, server := range servers {
+				if server.URL == owner {
+					continue
+				}
+
+				value, err := groups[i].Get(ctx, "key0")
+				So(err, ShouldBeNil)
+				So(string(value), ShouldEqual, "value of key0")
+				So(groups[i].Stats().PeerErrors, ShouldEqual, 1)
+			}
+			So(src.loads["key0"], ShouldEqual, 2)
+		})
+
+		Convey("Given invalid requests, the pool rejects them", func() {
+			_, err := pools[0].NewGroup("things", 10, src.get)
+			So(err, ShouldEqual, ErrDuplicateGroup)
+
+			_, err = pools[0].fetch(ctx, servers[1].URL, "nothing", "key0")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "404")
+		})
+	})
+}
+
+func TestNewGroup(t *testing.T) {
+	Convey("Given a taken group name, NewGroup builds no caches", t, func() {
+		pool := NewHTTPPool("http://127.0.0.1:0")
+		src := &source{loads: make(map[string]int)}
+		_, err := pool.NewGroup("things", 10, src.get)
+		So(err, ShouldBeNil)
+
+		// Each cache built with a janitor would start a goroutine.
+		goroutines := runtime.NumGoroutine()
+		for i := 0; i < 10; i++ {
+			_, err = pool.NewGroup("things", 10, src.get, lru_cache.WithJanitor(time.Hour))
+			So(err, ShouldEqual, ErrDuplicateGroup)
+		}
+		So(runtime.NumGoroutine(), ShouldEqual, goroutines)
+	})
+}
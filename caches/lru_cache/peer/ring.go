@@ -0,0 +1,84 @@
+// Package peer shares a lru_cache across replicas, in the manner of groupcache:
+// each key is owned by one peer, chosen by a consistent-hash ring, which loads
+// and caches it. Other peers fetch the key from its owner over HTTP, and keep
+// it in a small hot cache of their own.
+package peer
+
+import (
+	"hash/crc32"
+	"slices"
+	"strconv"
+)
+
+// Hash maps bytes to a position on a Ring.
+type Hash func(data []byte) uint32
+
+// Ring is a consistent-hash ring, which maps keys to peers such that adding or
+// removing a peer only moves the keys of that peer. Each peer is placed on the
+// ring several times, as virtual nodes, so that keys spread evenly.
+// Ring is not safe for concurrent modification.
+type Ring struct {
+	hash     Hash
+	replicas int
+	// points are the sorted positions of the virtual nodes, each owned by a peer.
+	points []uint32
+	owners map[uint32]string
+	peers  map[string]bool
+}
+
+// NewRing returns an empty ring that places each peer replicas times.
+// A nil hash defaults to CRC-32.
+func NewRing(replicas int, hash Hash) *Ring {
+	if hash == nil {
+		hash = crc32.ChecksumIEEE
+	}
+
+	return &Ring{
+		hash:     hash,
+		replicas: max(1, replicas),
+		owners:   make(map[uint32]string),
+		peers:    make(map[string]bool),
+	}
+}
+
+// Len returns the number of peers on the ring.
+func (ring *Ring) Len() int {
+	return len(ring.peers)
+}
+
+// Add places the passed peers on the ring, ignoring any already on it.
+func (ring *Ring) Add(peers ...string) {
+	for _, peer := range peers {
+		if ring.peers[peer] {
+			continue
+		}
+		ring.peers[peer] = true
+
+		for i := 0; i < ring.replicas; i++ {
+			point := ring.hash([]byte(strconv.Itoa(i) + peer))
+			// On the rare collision, the first peer keeps the point.
+			if _, ok := ring.owners[point]; ok {
+				continue
+			}
+			ring.owners[point] = peer
+			ring.points = append(ring.points, point)
+		}
+	}
+	slices.Sort(ring.points)
+}
+
+// Get returns the peer that owns key: that of the first virtual node at or
+// after the key's position, wrapping around. It returns false if the ring is empty.
+func (ring *Ring) Get(key string) (string, bool) {
+	if len(ring.points) == 0 {
+		return "", false
+	}
+
+	h := ring.hash([]byte(key))
+	i, _ := slices.BinarySearch(ring.points, h)
+	if i == len(ring.points) {
+		i = 0
+	}
+
+	return ring.owners[ring.points[i]], true
+}
@@ -0,0 +1,66 @@
+package peer
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRing(t *testing.T) {
+	Convey("Ring tests", t, func() {
+		Convey("Given an empty ring, Get fails", func() {
+			ring := NewRing(3, nil)
+			_, ok := ring.Get("abc")
+			So(ok, ShouldBeFalse)
+			So(ring.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Given a hash, keys belong to the next virtual node, wrapping around", func() {
+			// The hash of "<replica><peer>" is the peer's number times ten plus the replica.
+			hash := func(data []byte) uint32 {
+				var n uint32
+				fmt.Sscan(string(data), &n)
+				return n
+			}
+			ring := NewRing(2, hash)
+			// Virtual nodes at 2, 12; 4, 14; 6, 16.
+			ring.Add("2", "4", "6")
+			So(ring.Len(), ShouldEqual, 3)
+
+			for key, owner := range map[string]string{"1": "2", "2": "2", "3": "4", "11": "2", "13": "4", "16": "6", "17": "2"} {
+				got, ok := ring.Get(key)
+				So(ok, ShouldBeTrue)
+				So(got, ShouldEqual, owner)
+			}
+		})
+
+		Convey("Given a new peer, only keys it now owns move", func() {
+			ring := NewRing(DefaultReplicas, nil)
+			ring.Add("a", "b", "c")
+			owners := make(map[string]string)
+			counts := make(map[string]int)
+			for i := 0; i < 3000; i++ {
+				key := fmt.Sprint("key", i)
+				owners[key], _ = ring.Get(key)
+				counts[owners[key]]++
+			}
+			// Keys are spread roughly evenly.
+			for _, peer := range []string{"a", "b", "c"} {
+				So(counts[peer], ShouldBeBetween, 500, 1500)
+			}
+
+			ring.Add("d", "a")
+			So(ring.Len(), ShouldEqual, 4)
+			moved := 0
+			for key, owner := range owners {
+				got, _ := ring.Get(key)
+				if got != owner {
+					So(got, ShouldEqual, "d")
+					moved++
+				}
+			}
+			So(moved, ShouldBeBetween, 300, 1500)
+		})
+	})
+}
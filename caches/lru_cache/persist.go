@@ -0,0 +1,218 @@
+package lru_cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrInvalidSnapshot is returned by LoadFrom if its input is not a snapshot
+// written by SaveTo in the cache's format.
+var ErrInvalidSnapshot error = errors.New("invalid cache snapshot")
+
+// snapshotVersion is written in each snapshot's header, and checked on load.
+const snapshotVersion = 1
+
+// SnapshotFormat selects the encoding of snapshots written by SaveTo.
+type SnapshotFormat int
+
+const (
+	// GobFormat snapshots are encoded with encoding/gob; this is the default.
+	GobFormat SnapshotFormat = iota
+	// JSONFormat snapshots are a stream of JSON objects.
+	JSONFormat
+)
+
+func (format SnapshotFormat) String() string {
+	switch format {
+	case GobFormat:
+		return "gob"
+	case JSONFormat:
+		return "json"
+	}
+	return "unknown"
+}
+
+// Codec marshals cached items to bytes for snapshots, and back.
+type Codec[V any] interface {
+	Marshal(item V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// GobCodec is a Codec using encoding/gob, the default for GobFormat snapshots.
+type GobCodec[V any] struct{}
+
+func (GobCodec[V]) Marshal(item V) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(&item)
+	return buf.Bytes(), err
+}
+
+func (GobCodec[V]) Unmarshal(data []byte) (item V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&item)
+	return
+}
+
+// JSONCodec is a Codec using encoding/json, the default for JSONFormat snapshots.
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Marshal(item V) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (JSONCodec[V]) Unmarshal(data []byte) (item V, err error) {
+	err = json.Unmarshal(data, &item)
+	return
+}
+
+// snapshotHeader precedes a snapshot's entries.
+type snapshotHeader struct {
+	Version int
+	Count   int
+}
+
+// snapshotEntry is a cached item, whose Value is encoded by the cache's codec.
+// Expires is the zero time if the item never expires.
+type snapshotEntry[K comparable] struct {
+	Key     K
+	Value   []byte
+	Expires time.Time
+}
+
+// encoder and decoder are implemented by both gob and json.
+type encoder interface {
+	Encode(v any) error
+}
+
+type decoder interface {
+	Decode(v any) error
+}
+
+// codecs returns the cache's value codec, and the encoder and decoder constructors of its format.
+func (cache *Cache[K, V]) codecs() (Codec[V], func(io.Writer) encoder, func(io.Reader) decoder) {
+	codec := cache.codec
+	if cache.snapshotFormat == JSONFormat {
+		if codec == nil {
+			codec = JSONCodec[V]{}
+		}
+		return codec,
+			func(w io.Writer) encoder { return json.NewEncoder(w) },
+			func(r io.Reader) decoder { return json.NewDecoder(r) }
+	}
+
+	if codec == nil {
+		codec = GobCodec[V]{}
+	}
+	return codec,
+		func(w io.Writer) encoder { return gob.NewEncoder(w) },
+		func(r io.Reader) decoder { return gob.NewDecoder(r) }
+}
+
+// SaveTo writes a snapshot of the cache's unexpired items to w, from most to
+// least recently used, in the format selected by WithSnapshotFormat. Items are
+// marshaled by the codec set by WithCodec, or otherwise in the same format.
+// The cache is only locked while its items are listed, not while they are written.
+func (cache *Cache[K, V]) SaveTo(w io.Writer) error {
+	items := cache.entries()
+
+	codec, newEncoder, _ := cache.codecs()
+	enc := newEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, Count: len(items)}); err != nil {
+		return err
+	}
+
+	for _, e := range items {
+		value, err := codec.Marshal(e.item)
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(snapshotEntry[K]{
+			Key:     e.key,
+			Value:   value,
+			Expires: e.expires,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadFrom restores a snapshot written by SaveTo, such that its items are
+// the cache's most recently used, in their saved order. Items already in the
+// cache are kept in place of their saved values, items that have since expired
+// are skipped, as are the least recently used items that do not fit the cache's
+// capacity. Existing items keep their place in the recency order. Restored items are not saved to the cache's store.
+// If the snapshot cannot be read, LoadFrom returns an error without changing the cache.
+func (cache *Cache[K, V]) LoadFrom(r io.Reader) error {
+	codec, _, newDecoder := cache.codecs()
+	dec := newDecoder(r)
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+	if header.Version != snapshotVersion || header.Count < 0 {
+		return ErrInvalidSnapshot
+	}
+
+	cache.mu.RLock()
+	capacity := cache.capacity
+	cache.mu.RUnlock()
+
+	// Only the most recently used unexpired items which fit the cache are
+	// restored, but every entry is read, such that a truncated snapshot is detected.
+	items := make([]entry[K, V], 0, min(header.Count, capacity))
+	now := cache.clock.Now()
+	for i := 0; i < header.Count; i++ {
+		var e snapshotEntry[K]
+		if err := dec.Decode(&e); err != nil {
+			return err
+		}
+		if len(items) == capacity || (!e.Expires.IsZero() && !now.Before(e.Expires)) {
+			continue
+		}
+
+		item, err := codec.Unmarshal(e.Value)
+		if err != nil {
+			return err
+		}
+		items = append(items, entry[K, V]{
+			key:     e.Key,
+			item:    item,
+			expires: e.Expires,
+		})
+	}
+
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	// The cache may have been resized while the snapshot was read.
+	items = items[:min(len(items), cache.capacity)]
+
+	// Items are added from least to most recently used, so that each is
+	// moved in front of the last.
+	for i := len(items) - 1; i >= 0; i-- {
+		l := items[i]
+		if existing, ok := cache.itemMap[l.key]; ok && !cache.expired(existing) {
+			continue
+		}
+
+		var ttl time.Duration
+		if !l.expires.IsZero() {
+			ttl = l.expires.Sub(now)
+		}
+
+		cost, err := cache.costOf(l.item)
+		if err != nil {
+			continue
+		}
+		cache.add(l.key, l.item, ttl, cost)
+	}
+
+	return nil
+}
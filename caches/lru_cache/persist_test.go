@@ -0,0 +1,150 @@
+package lru_cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// decimalCodec marshals ints as decimal strings.
+type decimalCodec struct{}
+
+func (decimalCodec) Marshal(item int) ([]byte, error) {
+	return []byte(strconv.Itoa(item)), nil
+}
+
+func (decimalCodec) Unmarshal(data []byte) (int, error) {
+	return strconv.Atoi(string(data))
+}
+
+// keysOf returns the cache's keys from most to least recently used.
+func keysOf[K comparable, V any](cache *Cache[K, V]) (keys []K) {
+	for current := cache.itemList.head; current != nil; current = current.next {
+		keys = append(keys, current.key)
+	}
+	return
+}
+
+func TestSnapshots(t *testing.T) {
+	Convey("Snapshot tests", t, func() {
+		clock := newFakeClock()
+
+		for _, format := range []SnapshotFormat{GobFormat, JSONFormat} {
+			Convey("Given a "+format.String()+" snapshot, it is restored in recency order", func() {
+				cache, err := New[string, int](10, WithClock(clock), WithSnapshotFormat(format))
+				So(err, ShouldBeNil)
+				for i, key := range []string{"a", "b", "c", "d"} {
+					So(cache.Put(key, i), ShouldBeNil)
+				}
+				So(cache.PutWithTTL("e", 4, time.Minute), ShouldBeNil)
+				_, _ = cache.Get("b")
+
+				var buf bytes.Buffer
+				So(cache.SaveTo(&buf), ShouldBeNil)
+
+				restored, err := New[string, int](10, WithClock(clock), WithSnapshotFormat(format))
+				So(err, ShouldBeNil)
+				So(restored.LoadFrom(&buf), ShouldBeNil)
+				So(keysOf(restored), ShouldResemble, []string{"b", "e", "d", "c", "a"})
+
+				val, ok := restored.Get("c")
+				So(ok, ShouldBeTrue)
+				So(val, ShouldEqual, 2)
+
+				// TTLs are restored as absolute expiry times.
+				clock.Advance(time.Minute)
+				_, ok = restored.Get("e")
+				So(ok, ShouldBeFalse)
+			})
+		}
+
+		Convey("Given a custom codec, items are marshaled by it", func() {
+			cache, err := New[string, int](10, WithSnapshotFormat(JSONFormat), WithCodec[int](decimalCodec{}))
+			So(err, ShouldBeNil)
+			So(cache.Put("a", 42), ShouldBeNil)
+
+			var buf bytes.Buffer
+			So(cache.SaveTo(&buf), ShouldBeNil)
+			// []byte values are base64-encoded by encoding/json; "NDI=" is "42".
+			So(buf.String(), ShouldContainSubstring, `"Value":"NDI="`)
+
+			restored, err := New[string, int](10, WithSnapshotFormat(JSONFormat), WithCodec[int](decimalCodec{}))
+			So(err, ShouldBeNil)
+			So(restored.LoadFrom(&buf), ShouldBeNil)
+			val, ok := restored.Get("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 42)
+
+			_, err = New[string, string](10, WithCodec[int](decimalCodec{}))
+			So(err, ShouldBeError, ErrInvalidOption)
+			_, err = New[string, int](10, WithSnapshotFormat(JSONFormat+1))
+			So(err, ShouldBeError, ErrInvalidOption)
+		})
+
+		Convey("Given a snapshot larger than the cache, the most recently used items are restored", func() {
+			cache, err := New[int, int](10, WithClock(clock))
+			So(err, ShouldBeNil)
+			for i := 0; i < 10; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+			So(cache.PutWithTTL(10, 10, time.Second), ShouldBeNil)
+
+			var buf bytes.Buffer
+			So(cache.SaveTo(&buf), ShouldBeNil)
+			clock.Advance(time.Second)
+
+			restored, err := New[int, int](3, WithClock(clock))
+			So(err, ShouldBeNil)
+			So(restored.Put(9, 90), ShouldBeNil)
+			So(restored.LoadFrom(&buf), ShouldBeNil)
+
+			// 10 has expired, and the existing 9 is kept.
+			So(keysOf(restored), ShouldResemble, []int{8, 7, 9})
+			val, _ := restored.Get(9)
+			So(val, ShouldEqual, 90)
+		})
+
+		Convey("Given a cache resized during LoadFrom, it holds no more than its capacity", func() {
+			cache, err := New[int, int](100)
+			So(err, ShouldBeNil)
+			for i := 0; i < 100; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+			var buf bytes.Buffer
+			So(cache.SaveTo(&buf), ShouldBeNil)
+
+			restored, err := New[int, int](100)
+			So(err, ShouldBeNil)
+			done := make(chan error)
+			go func() {
+				done <- restored.LoadFrom(&buf)
+			}()
+			So(restored.Resize(10), ShouldBeNil)
+			So(<-done, ShouldBeNil)
+			So(restored.Len(), ShouldBeLessThanOrEqualTo, 10)
+		})
+
+		Convey("Given an invalid snapshot, LoadFrom fails without changing the cache", func() {
+			cache, err := New[string, int](10)
+			So(err, ShouldBeNil)
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("b", 2), ShouldBeNil)
+
+			var buf bytes.Buffer
+			So(cache.SaveTo(&buf), ShouldBeNil)
+
+			restored, err := New[string, int](10)
+			So(err, ShouldBeNil)
+			So(restored.LoadFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-4])), ShouldNotBeNil)
+			So(restored.Len(), ShouldEqual, 0)
+
+			var bad bytes.Buffer
+			So(gob.NewEncoder(&bad).Encode(snapshotHeader{Version: snapshotVersion + 1}), ShouldBeNil)
+			So(restored.LoadFrom(&bad), ShouldEqual, ErrInvalidSnapshot)
+		})
+	})
+}
@@ -0,0 +1,78 @@
+package lru_cache
+
+// EvictionPolicy chooses which keys a Cache evicts when it is over capacity.
+// Policies are not safe for concurrent use; the Cache calls them under its lock.
+type EvictionPolicy[K comparable] interface {
+	// Add records that key was inserted into the cache.
+	Add(key K)
+	// Access records a cache hit on key.
+	Access(key K)
+	// Remove forgets key, which left the cache other than via Evict.
+	Remove(key K)
+	// Evict forgets and returns the key that should leave the cache next,
+	// or returns false if the policy holds no keys.
+	Evict() (key K, ok bool)
+	// Resize adapts the policy to the cache's new capacity. The cache then
+	// calls Evict while it is over capacity.
+	Resize(capacity int)
+}
+
+// compactor is implemented by policies whose maps may be rebuilt to release
+// memory after the cache shrinks.
+type compactor interface {
+	compact()
+}
+
+// Policy names an EvictionPolicy implementation, selected via WithPolicy.
+type Policy int
+
+const (
+	// LRU evicts the least-recently-used item.
+	LRU Policy = iota
+	// LFU evicts the least-frequently-used item, or the least recent among equals.
+	LFU
+	// TwoQueue is a segmented variant of 2Q: new items enter a probationary FIFO
+	// and are promoted to an LRU queue when accessed again, such that one-time
+	// scans only evict each other. Keys evicted from the FIFO are remembered,
+	// and promoted directly if they are re-added.
+	TwoQueue
+	// ARC is the Adaptive Replacement Cache, which balances recency and
+	// frequency by tracking recently evicted keys from each.
+	ARC
+	// TinyLFU is W-TinyLFU: items enter a small LRU window, and then must beat
+	// the main segmented LRU's victim per a count-min sketch of access frequency
+	// to be admitted to it.
+	TinyLFU
+)
+
+func (policy Policy) String() string {
+	switch policy {
+	case LRU:
+		return "lru"
+	case LFU:
+		return "lfu"
+	case TwoQueue:
+		return "2q"
+	case ARC:
+		return "arc"
+	case TinyLFU:
+		return "tinylfu"
+	}
+	return "unknown"
+}
+
+// newEvictionPolicy returns the named policy for a cache of the passed capacity,
+// or nil for LRU, which the Cache implements directly via its list.
+func newEvictionPolicy[K comparable](policy Policy, capacity int) EvictionPolicy[K] {
+	switch policy {
+	case LFU:
+		return newLFUPolicy[K]()
+	case TwoQueue:
+		return newTwoQueuePolicy[K](capacity)
+	case ARC:
+		return newARCPolicy[K](capacity)
+	case TinyLFU:
+		return newTinyLFUPolicy[K](capacity)
+	}
+	return nil
+}
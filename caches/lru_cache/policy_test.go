@@ -0,0 +1,182 @@
+package lru_cache
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var policies = []Policy{LRU, LFU, TwoQueue, ARC, TinyLFU}
+
+func TestPolicies(t *testing.T) {
+	Convey("Eviction policy tests", t, func() {
+		Convey("Given random operations, every policy keeps the cache within capacity", func() {
+			for _, policy := range policies {
+				capacity := 50
+				cache, err := New[int, int](capacity, WithPolicy(policy))
+				So(err, ShouldBeNil)
+
+				evicted := 0
+				cache.OnEvict(func(key, item int, reason EvictionReason) {
+					if reason == EvictedCapacity {
+						evicted++
+					}
+				})
+
+				r := rand.New(rand.NewSource(int64(policy)))
+				puts := 0
+				for i := 0; i < 5000; i++ {
+					key := r.Intn(200)
+					switch r.Intn(4) {
+					case 0:
+						if cache.Put(key, i) == nil {
+							puts++
+						}
+					case 1:
+						if cache.Remove(key) == nil {
+							puts--
+						}
+					default:
+						if val, ok := cache.Get(key); ok {
+							So(val, ShouldBeLessThan, i)
+						}
+					}
+
+					So(cache.itemList.count, ShouldBeLessThanOrEqualTo, capacity)
+				}
+
+				So(len(cache.itemMap), ShouldEqual, cache.itemList.count)
+				So(puts-evicted, ShouldEqual, cache.itemList.count)
+			}
+		})
+
+		Convey("Given a hot working set, a one-time scan only evicts it under LRU", func() {
+			for _, policy := range policies {
+				cache, err := New[int, int](100, WithPolicy(policy))
+				So(err, ShouldBeNil)
+
+				hot := 20
+				for key := 0; key < hot; key++ {
+					So(cache.Put(key, key), ShouldBeNil)
+				}
+				for i := 0; i < 5; i++ {
+					for key := 0; key < hot; key++ {
+						_, ok := cache.Get(key)
+						So(ok, ShouldBeTrue)
+					}
+				}
+
+				for key := 1000; key < 1500; key++ {
+					So(cache.Put(key, key), ShouldBeNil)
+				}
+
+				survivors := 0
+				for key := 0; key < hot; key++ {
+					if _, ok := cache.itemMap[key]; ok {
+						survivors++
+					}
+				}
+
+				if policy == LRU {
+					So(survivors, ShouldEqual, 0)
+				} else {
+					So(survivors, ShouldEqual, hot)
+				}
+			}
+		})
+
+		Convey("LFU evicts the least-frequently-used item, then the least recent", func() {
+			cache, err := New[string, int](3, WithPolicy(LFU))
+			So(err, ShouldBeNil)
+
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("b", 2), ShouldBeNil)
+			So(cache.Put("c", 3), ShouldBeNil)
+			_, _ = cache.Get("a")
+			_, _ = cache.Get("a")
+			_, _ = cache.Get("c")
+
+			// b is least frequent.
+			So(cache.Put("d", 4), ShouldBeNil)
+			_, ok := cache.itemMap["b"]
+			So(ok, ShouldBeFalse)
+
+			// d and the new e are least frequent, and d the less recent.
+			So(cache.Remove("a"), ShouldBeNil)
+			So(cache.Put("e", 5), ShouldBeNil)
+			So(cache.Put("f", 6), ShouldBeNil)
+			_, ok = cache.itemMap["d"]
+			So(ok, ShouldBeFalse)
+			So(cache.itemList.count, ShouldEqual, 3)
+		})
+
+		Convey("TwoQueue promotes re-added keys that were recently evicted", func() {
+			cache, err := New[int, int](4, WithPolicy(TwoQueue))
+			So(err, ShouldBeNil)
+			policy := cache.policy.(*twoQueuePolicy[int])
+
+			for key := 0; key < 5; key++ {
+				So(cache.Put(key, key), ShouldBeNil)
+			}
+			// Key 0 was evicted from probation and is now a ghost.
+			So(policy.nodes[0].item, ShouldEqual, ghostQueue)
+			So(cache.Put(0, 0), ShouldBeNil)
+			So(policy.nodes[0].item, ShouldEqual, frequentQueue)
+		})
+
+		Convey("ARC adapts its target toward recency on B1 ghost hits", func() {
+			cache, err := New[int, int](4, WithPolicy(ARC))
+			So(err, ShouldBeNil)
+			policy := cache.policy.(*arcPolicy[int])
+
+			for key := 0; key < 4; key++ {
+				So(cache.Put(key, key), ShouldBeNil)
+			}
+			// Key 1 moves to T2, leaving room in the ghost list B1 for key 0.
+			_, _ = cache.Get(1)
+			So(cache.Put(4, 4), ShouldBeNil)
+			So(policy.nodes[0].item, ShouldEqual, arcB1)
+			So(policy.p, ShouldEqual, 0)
+
+			So(cache.Put(0, 0), ShouldBeNil)
+			So(policy.p, ShouldEqual, 1)
+			So(policy.nodes[0].item, ShouldEqual, arcT2)
+		})
+
+		Convey("Invalid policies are rejected", func() {
+			_, err := New[int, int](1, WithPolicy(Policy(-1)))
+			So(err, ShouldBeError, ErrInvalidOption)
+			So(Policy(-1).String(), ShouldEqual, "unknown")
+			So(TinyLFU.String(), ShouldEqual, "tinylfu")
+		})
+	})
+}
+
+func TestCountMinSketch(t *testing.T) {
+	Convey("Count-min sketch tests", t, func() {
+		sketch := newCountMinSketch[int](64)
+
+		Convey("Estimates never undercount, and saturate", func() {
+			for i := 0; i < 10; i++ {
+				sketch.Increment(1)
+			}
+			for i := 0; i < 20; i++ {
+				sketch.Increment(2)
+			}
+
+			So(sketch.Estimate(1), ShouldBeGreaterThanOrEqualTo, 10)
+			So(sketch.Estimate(2), ShouldEqual, sketchMax)
+		})
+
+		Convey("Counters are halved once enough increments have been made", func() {
+			for i := 0; i < 8; i++ {
+				sketch.Increment(1)
+			}
+			for i := 0; i < sketch.resetAt; i++ {
+				sketch.Increment(1000 + i)
+			}
+			So(sketch.Estimate(1), ShouldBeLessThan, 8)
+		})
+	})
+}
@@ -0,0 +1,98 @@
+package lru_cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// OnRefreshError registers fn to be called when a refresh started per
+// WithRefresh fails, replacing any previous callback; a nil fn removes it.
+// The stale item is kept until it expires, and the next Get of it retries
+// the refresh. fn is called without the cache lock held.
+func (cache *Cache[K, V]) OnRefreshError(fn func(key K, err error)) {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	cache.onRefreshError = fn
+}
+
+// stale returns true if target is due to be refreshed.
+func (cache *Cache[K, V]) stale(target *node[K, V]) bool {
+	return !target.stale.IsZero() && !cache.clock.Now().Before(target.stale)
+}
+
+// startRefresh refreshes target in the background, unless it is already being
+// refreshed, or the cache is closed. The caller must hold the write lock.
+func (cache *Cache[K, V]) startRefresh(target *node[K, V]) {
+	if cache.refresher == nil || target.refreshing || cache.refreshCtx.Err() != nil {
+		return
+	}
+
+	target.refreshing = true
+	cache.refreshes.Add(1)
+	go cache.refresh(target, target.generation)
+}
+
+// refresh loads target's item again, and reports any error. generation is that
+// of target when the refresh started.
+func (cache *Cache[K, V]) refresh(target *node[K, V], generation uint64) {
+	defer cache.refreshes.Done()
+
+	item, err := cache.loadRefresh(target.key)
+	if onRefreshError, err := cache.completeRefresh(target, generation, item, err); err != nil && onRefreshError != nil {
+		onRefreshError(target.key, err)
+	}
+}
+
+// loadRefresh calls the refresher, converting a panic into an error.
+func (cache *Cache[K, V]) loadRefresh(key K) (item V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("lru_cache: refresh panicked: %v", r)
+		}
+	}()
+
+	return cache.refresher(cache.refreshCtx, key)
+}
+
+// completeRefresh replaces target's item with the refreshed item, unless the
+// refresh failed, target left the cache or was replaced, e.g. by Set, while it
+// was refreshed, or the cache was closed. It returns any error to report, e.g. that the item is too
+// large, and the callback to which it should be reported.
+func (cache *Cache[K, V]) completeRefresh(target *node[K, V], generation uint64, item V, err error) (func(key K, err error), error) {
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	target.refreshing = false
+	if cache.refreshCtx.Err() != nil {
+		return nil, nil
+	}
+	if err != nil {
+		return cache.onRefreshError, err
+	}
+	if cache.itemMap[target.key] != target || target.generation != generation {
+		return nil, nil
+	}
+
+	cost, err := cache.costOf(item)
+	if err != nil {
+		return cache.onRefreshError, err
+	}
+
+	// The refreshed item has the same TTL as target, which became stale softTTL
+	// after it was set.
+	var ttl time.Duration
+	if !target.expires.IsZero() {
+		ttl = target.expires.Sub(target.stale) + cache.softTTL
+	}
+
+	cache.notifyEvicted(target, EvictedReplaced)
+	target.item = item
+	target.generation++
+	cache.cost += cost - target.cost
+	target.cost = cost
+	cache.setExpiry(target, ttl)
+	cache.evictOverCapacity()
+
+	return nil, nil
+}
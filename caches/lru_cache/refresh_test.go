@@ -0,0 +1,181 @@
+package lru_cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRefresh(t *testing.T) {
+	Convey("Stale-while-revalidate tests", t, func() {
+		clock := newFakeClock()
+
+		var calls int32
+		started := make(chan string, 10)
+		results := make(chan error, 10)
+		contexts := make(chan context.Context, 10)
+		loader := func(ctx context.Context, key string) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			contexts <- ctx
+			started <- key
+			if err := <-results; err != nil {
+				return 0, err
+			}
+			return 2, nil
+		}
+
+		cache, err := New[string, int](10, WithClock(clock), WithDefaultTTL(time.Hour), WithRefresh(time.Minute, loader))
+		So(err, ShouldBeNil)
+
+		refreshed := make(chan int, 10)
+		cache.OnEvict(func(key string, item int, reason EvictionReason) {
+			if reason == EvictedReplaced {
+				refreshed <- item
+			}
+		})
+		failed := make(chan error, 10)
+		cache.OnRefreshError(func(key string, err error) {
+			failed <- err
+		})
+
+		So(cache.Put("a", 1), ShouldBeNil)
+
+		Convey("Given a fresh item, Get does not refresh it", func() {
+			clock.Advance(time.Minute - time.Second)
+			val, ok := cache.Get("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 0)
+		})
+
+		Convey("Given a stale item, Get returns it and refreshes it once in the background", func() {
+			clock.Advance(time.Minute)
+			for i := 0; i < 5; i++ {
+				val, ok := cache.Get("a")
+				So(ok, ShouldBeTrue)
+				So(val, ShouldEqual, 1)
+			}
+			So(<-started, ShouldEqual, "a")
+
+			results <- nil
+			So(<-refreshed, ShouldEqual, 1)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+
+			val, ok := cache.Get("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 2)
+
+			// The refreshed item is fresh for another soft TTL, and expires an hour after its refresh.
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			clock.Advance(time.Hour - time.Second)
+			So(cache.Contains("a"), ShouldBeTrue)
+		})
+
+		Convey("Given a failed refresh, the stale item is kept and the error reported", func() {
+			clock.Advance(time.Minute)
+			_, _ = cache.Get("a")
+			<-started
+			results <- errBackend
+			So(<-failed, ShouldEqual, errBackend)
+
+			val, ok := cache.Peek("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+
+			// The next Get retries the refresh.
+			_, _ = cache.Get("a")
+			<-started
+			results <- nil
+			So(<-refreshed, ShouldEqual, 1)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 2)
+		})
+
+		Convey("Given an item past its TTL, Get misses", func() {
+			clock.Advance(time.Hour)
+			_, ok := cache.Get("a")
+			So(ok, ShouldBeFalse)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 0)
+		})
+
+		Convey("Given a removed item, its refresh is discarded", func() {
+			clock.Advance(time.Minute)
+			_, _ = cache.Get("a")
+			<-started
+			So(cache.Remove("a"), ShouldBeNil)
+			results <- nil
+
+			// Wait for the refresh to complete by refreshing another key after it.
+			So(cache.Put("b", 1), ShouldBeNil)
+			clock.Advance(time.Minute)
+			_, _ = cache.Get("b")
+			<-started
+			results <- nil
+			So(<-refreshed, ShouldEqual, 1)
+			So(cache.Contains("a"), ShouldBeFalse)
+		})
+
+		Convey("Given an item set during its refresh, the refresh is discarded", func() {
+			clock.Advance(time.Minute)
+			_, _ = cache.Get("a")
+			<-started
+			So(cache.Set("a", 3), ShouldBeNil)
+			So(<-refreshed, ShouldEqual, 1)
+			results <- nil
+
+			// Wait for the refresh to complete by refreshing another key after it.
+			So(cache.Put("b", 1), ShouldBeNil)
+			clock.Advance(time.Minute)
+			_, _ = cache.Get("b")
+			<-started
+			results <- nil
+			So(<-refreshed, ShouldEqual, 1)
+
+			val, ok := cache.Peek("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 3)
+		})
+
+		Convey("Given a refresh in flight, Close cancels it and waits for it", func() {
+			var evictions int32
+			cache.OnEvict(func(key string, item int, reason EvictionReason) {
+				atomic.AddInt32(&evictions, 1)
+			})
+			clock.Advance(time.Minute)
+			_, _ = cache.Get("a")
+			<-started
+
+			closed := make(chan error)
+			go func() {
+				closed <- cache.Close()
+			}()
+			<-(<-contexts).Done()
+			select {
+			case <-closed:
+				So("Close returned before the refresh", ShouldBeEmpty)
+			default:
+			}
+			results <- nil
+			So(<-closed, ShouldBeNil)
+
+			// The refresh is discarded, and no more are started.
+			So(atomic.LoadInt32(&evictions), ShouldEqual, 0)
+			val, ok := cache.Peek("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+			_, _ = cache.Get("a")
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+		})
+
+		Convey("Given invalid options, New fails", func() {
+			_, err := New[string, int](10, WithRefresh[string, int](0, loader))
+			So(err, ShouldEqual, ErrInvalidOption)
+			_, err = New[string, int](10, WithRefresh[string, int](time.Minute, nil))
+			So(err, ShouldEqual, ErrInvalidOption)
+			_, err = New[int, int](10, WithRefresh(time.Minute, loader))
+			So(err, ShouldEqual, ErrInvalidOption)
+		})
+	})
+}
@@ -0,0 +1,40 @@
+package lru_cache
+
+// Resize sets the cache's capacity to the passed number of items, immediately
+// evicting items as chosen by the policy if it shrinks, or returns ErrInvalidSize.
+// After the capacity at least halves, the cache's maps are rebuilt to release
+// the memory of the evicted items' entries.
+func (cache *Cache[K, V]) Resize(capacity int) error {
+	if capacity <= 0 {
+		return ErrInvalidSize
+	}
+
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	previous := cache.capacity
+	cache.capacity = capacity
+	if cache.policy != nil {
+		cache.policy.Resize(capacity)
+	}
+	cache.evictOverCapacity()
+
+	if capacity <= previous/2 {
+		cache.itemMap = compactMap(cache.itemMap)
+		if c, ok := cache.policy.(compactor); ok {
+			c.compact()
+		}
+	}
+
+	return nil
+}
+
+// compactMap returns a copy of m, whose memory is proportional to its current
+// length rather than the most it ever held.
+func compactMap[K comparable, V any](m map[K]V) map[K]V {
+	compacted := make(map[K]V, len(m))
+	for key, value := range m {
+		compacted[key] = value
+	}
+	return compacted
+}
@@ -0,0 +1,86 @@
+package lru_cache
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResize(t *testing.T) {
+	Convey("Resize tests", t, func() {
+		Convey("Given an invalid capacity, Resize fails", func() {
+			cache, err := New[int, int](10)
+			So(err, ShouldBeNil)
+			So(cache.Resize(0), ShouldEqual, ErrInvalidSize)
+			So(cache.capacity, ShouldEqual, 10)
+		})
+
+		Convey("Given a shrinking capacity, the least-recently-used items are evicted immediately", func() {
+			cache, err := New[int, int](10)
+			So(err, ShouldBeNil)
+
+			var evicted []int
+			cache.OnEvict(func(key, item int, reason EvictionReason) {
+				So(reason, ShouldEqual, EvictedCapacity)
+				evicted = append(evicted, key)
+			})
+
+			for i := 0; i < 10; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+			_, _ = cache.Get(0)
+
+			So(cache.Resize(3), ShouldBeNil)
+			So(evicted, ShouldResemble, []int{1, 2, 3, 4, 5, 6, 7})
+			So(keysOf(cache), ShouldResemble, []int{0, 9, 8})
+			So(len(cache.itemMap), ShouldEqual, 3)
+
+			So(cache.Resize(5), ShouldBeNil)
+			for i := 10; i < 12; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+			So(cache.Len(), ShouldEqual, 5)
+			So(cache.Stats().Capacity, ShouldEqual, 5)
+		})
+
+		Convey("Given any policy, the cache stays within its capacity as it is resized", func() {
+			for _, policy := range policies {
+				cache, err := New[int, int](100, WithPolicy(policy))
+				So(err, ShouldBeNil)
+
+				r := rand.New(rand.NewSource(int64(policy)))
+				for _, capacity := range []int{100, 10, 1, 40, 200, 7} {
+					So(cache.Resize(capacity), ShouldBeNil)
+					So(cache.itemList.count, ShouldBeLessThanOrEqualTo, capacity)
+
+					for i := 0; i < 1000; i++ {
+						key := r.Intn(300)
+						if r.Intn(2) == 0 {
+							_ = cache.Set(key, i)
+						} else {
+							_, _ = cache.Get(key)
+						}
+						So(cache.itemList.count, ShouldBeLessThanOrEqualTo, capacity)
+					}
+					So(len(cache.itemMap), ShouldEqual, cache.itemList.count)
+				}
+			}
+		})
+
+		Convey("Given a sharded cache, the capacity is divided across its shards", func() {
+			cache, err := NewSharded[int, int](4, 400)
+			So(err, ShouldBeNil)
+			for i := 0; i < 100; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+
+			So(cache.Resize(3), ShouldEqual, ErrInvalidSize)
+			So(cache.Resize(8), ShouldBeNil)
+			So(cache.Len(), ShouldBeLessThanOrEqualTo, 8)
+			for _, shard := range cache.shards {
+				So(shard.capacity, ShouldEqual, 2)
+			}
+		})
+	})
+}
@@ -0,0 +1,206 @@
+package lru_cache
+
+import (
+	"context"
+	"hash/maphash"
+	"time"
+)
+
+// ShardedCache spreads keys across independently locked Cache shards, such that
+// concurrent operations on different shards do not contend for the same lock.
+// Recency is tracked per shard, so eviction is only approximately least-recently-used
+// across the whole cache.
+type ShardedCache[K comparable, V any] struct {
+	shards []*Cache[K, V]
+	seed   maphash.Seed
+}
+
+// NewSharded initializes a cache of numShards shards with a total of capacity items,
+// which is divided evenly across them, as is any MaxCost. The passed options
+// otherwise apply to every shard.
+func NewSharded[K comparable, V any](numShards, capacity int, opts ...Option) (*ShardedCache[K, V], error) {
+	if numShards <= 0 || capacity < numShards {
+		return nil, ErrInvalidSize
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxCost > 0 {
+		// Round up as for capacity; the option is appended so that it takes precedence.
+		shardCost := (o.maxCost + int64(numShards) - 1) / int64(numShards)
+		opts = append(opts[:len(opts):len(opts)], WithMaxCost(shardCost))
+	}
+
+	sharded := &ShardedCache[K, V]{
+		shards: make([]*Cache[K, V], numShards),
+		seed:   maphash.MakeSeed(),
+	}
+
+	// Round up, so that the total capacity is at least that requested.
+	shardCapacity := (capacity + numShards - 1) / numShards
+	for i := range sharded.shards {
+		shard, err := New[K, V](shardCapacity, opts...)
+		if err != nil {
+			_ = sharded.Close()
+			return nil, err
+		}
+		sharded.shards[i] = shard
+	}
+
+	return sharded, nil
+}
+
+// shard returns the shard that owns key.
+func (sharded *ShardedCache[K, V]) shard(key K) *Cache[K, V] {
+	return sharded.shards[maphash.Comparable(sharded.seed, key)%uint64(len(sharded.shards))]
+}
+
+// Put adds the item to key's shard, per Cache.Put.
+func (sharded *ShardedCache[K, V]) Put(key K, item V) error {
+	return sharded.shard(key).Put(key, item)
+}
+
+// PutWithTTL adds the item to key's shard, per Cache.PutWithTTL.
+func (sharded *ShardedCache[K, V]) PutWithTTL(key K, item V, ttl time.Duration) error {
+	return sharded.shard(key).PutWithTTL(key, item, ttl)
+}
+
+// Set adds or replaces the item in key's shard, per Cache.Set.
+func (sharded *ShardedCache[K, V]) Set(key K, item V) error {
+	return sharded.shard(key).Set(key, item)
+}
+
+// SetWithTTL adds or replaces the item in key's shard, per Cache.SetWithTTL.
+func (sharded *ShardedCache[K, V]) SetWithTTL(key K, item V, ttl time.Duration) error {
+	return sharded.shard(key).SetWithTTL(key, item, ttl)
+}
+
+// Get finds the item in key's shard, per Cache.Get.
+func (sharded *ShardedCache[K, V]) Get(key K) (V, bool) {
+	return sharded.shard(key).Get(key)
+}
+
+// GetOrLoad finds or loads the item in key's shard, per Cache.GetOrLoad.
+func (sharded *ShardedCache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (V, error) {
+	return sharded.shard(key).GetOrLoad(ctx, key, loader)
+}
+
+// Peek finds the item in key's shard, per Cache.Peek.
+func (sharded *ShardedCache[K, V]) Peek(key K) (V, bool) {
+	return sharded.shard(key).Peek(key)
+}
+
+// Contains reports whether key's shard holds an item, per Cache.Contains.
+func (sharded *ShardedCache[K, V]) Contains(key K) bool {
+	return sharded.shard(key).Contains(key)
+}
+
+// PutWithTags adds the tagged item to key's shard, per Cache.PutWithTags.
+func (sharded *ShardedCache[K, V]) PutWithTags(key K, item V, tags ...string) error {
+	return sharded.shard(key).PutWithTags(key, item, tags...)
+}
+
+// InvalidateTag removes the items with the passed tag from every shard, per
+// Cache.InvalidateTag, and returns the number removed. Shards are invalidated
+// in turn, so other goroutines may see a partial invalidation.
+func (sharded *ShardedCache[K, V]) InvalidateTag(tag string) (removed int) {
+	for _, shard := range sharded.shards {
+		removed += shard.InvalidateTag(tag)
+	}
+	return
+}
+
+// InvalidatePrefixSharded removes the items whose keys have the passed prefix
+// from every shard of the string-keyed cache, per InvalidatePrefix, and returns
+// the number removed. Shards are invalidated in turn, as by InvalidateTag.
+func InvalidatePrefixSharded[V any](sharded *ShardedCache[string, V], prefix string) (removed int) {
+	for _, shard := range sharded.shards {
+		removed += InvalidatePrefix(shard, prefix)
+	}
+	return
+}
+
+// Remove deletes the item from key's shard, per Cache.Remove.
+func (sharded *ShardedCache[K, V]) Remove(key K) error {
+	return sharded.shard(key).Remove(key)
+}
+
+// Len returns the number of items across all shards.
+func (sharded *ShardedCache[K, V]) Len() (n int) {
+	for _, shard := range sharded.shards {
+		n += shard.Len()
+	}
+	return
+}
+
+// Cost returns the total cost of the items across all shards.
+func (sharded *ShardedCache[K, V]) Cost() (cost int64) {
+	for _, shard := range sharded.shards {
+		cost += shard.Cost()
+	}
+	return
+}
+
+// Resize divides the new capacity evenly across the shards, per Cache.Resize.
+// It returns ErrInvalidSize if the capacity is less than the number of shards.
+func (sharded *ShardedCache[K, V]) Resize(capacity int) error {
+	if capacity < len(sharded.shards) {
+		return ErrInvalidSize
+	}
+
+	shardCapacity := (capacity + len(sharded.shards) - 1) / len(sharded.shards)
+	for _, shard := range sharded.shards {
+		if err := shard.Resize(shardCapacity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnEvict registers fn with every shard, per Cache.OnEvict.
+func (sharded *ShardedCache[K, V]) OnEvict(fn func(key K, item V, reason EvictionReason)) {
+	for _, shard := range sharded.shards {
+		shard.OnEvict(fn)
+	}
+}
+
+// OnRefreshError registers fn with every shard, per Cache.OnRefreshError.
+func (sharded *ShardedCache[K, V]) OnRefreshError(fn func(key K, err error)) {
+	for _, shard := range sharded.shards {
+		shard.OnRefreshError(fn)
+	}
+}
+
+// DeleteExpired removes expired items from every shard and returns the number removed.
+func (sharded *ShardedCache[K, V]) DeleteExpired() (removed int) {
+	for _, shard := range sharded.shards {
+		removed += shard.DeleteExpired()
+	}
+	return
+}
+
+// Flush flushes every shard, per Cache.Flush, and returns the first error.
+func (sharded *ShardedCache[K, V]) Flush() (err error) {
+	for _, shard := range sharded.shards {
+		if shardErr := shard.Flush(); err == nil {
+			err = shardErr
+		}
+	}
+	return
+}
+
+// Close closes every shard, per Cache.Close, and returns the first error.
+func (sharded *ShardedCache[K, V]) Close() (err error) {
+	for _, shard := range sharded.shards {
+		// Shards are nil if New failed part way through.
+		if shard == nil {
+			continue
+		}
+		if shardErr := shard.Close(); err == nil {
+			err = shardErr
+		}
+	}
+	return
+}
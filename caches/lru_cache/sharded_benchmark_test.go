@@ -0,0 +1,62 @@
+package lru_cache
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// The benchmarks compare the single-lock Cache to ShardedCache under parallel
+// load; run them with varying -cpu, e.g. 'go test -bench Parallel -cpu 1,8,64'.
+const (
+	benchCapacity = 1 << 14
+	// The key space is larger than the capacity to force evictions.
+	benchKeys = benchCapacity * 2
+)
+
+type benchCache interface {
+	Put(int, int) error
+	Get(int) (int, bool)
+}
+
+// benchmarkParallel runs a read-heavy mix of one Put for every nine Gets.
+func benchmarkParallel(b *testing.B, cache benchCache) {
+	for i := 0; i < benchCapacity; i++ {
+		_ = cache.Put(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		i := 0
+		for pb.Next() {
+			key := r.Intn(benchKeys)
+			if i%10 == 0 {
+				_ = cache.Put(key, i)
+			} else {
+				_, _ = cache.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkCacheParallel(b *testing.B) {
+	cache, err := New[int, int](benchCapacity)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkParallel(b, cache)
+}
+
+func BenchmarkShardedCacheParallel(b *testing.B) {
+	for _, shards := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			cache, err := NewSharded[int, int](shards, benchCapacity)
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkParallel(b, cache)
+		})
+	}
+}
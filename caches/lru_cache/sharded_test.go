@@ -0,0 +1,109 @@
+package lru_cache
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestShardedCache(t *testing.T) {
+	Convey("Sharded cache tests", t, func() {
+		Convey("Given invalid sizes, NewSharded fails", func() {
+			_, err := NewSharded[int, int](0, 10)
+			So(err, ShouldBeError, ErrInvalidSize)
+			_, err = NewSharded[int, int](4, 3)
+			So(err, ShouldBeError, ErrInvalidSize)
+			_, err = NewSharded[int, int](4, 10, WithClock(nil))
+			So(err, ShouldBeError, ErrInvalidOption)
+		})
+
+		Convey("Given a sharded cache, Put, Get and Remove succeed", func() {
+			// Shards have room for every key, however they are spread.
+			cache, err := NewSharded[int, int](4, 400)
+			So(err, ShouldBeNil)
+			So(cache.shards, ShouldHaveLength, 4)
+			So(cache.shards[0].capacity, ShouldEqual, 100)
+
+			for i := 0; i < 100; i++ {
+				So(cache.Put(i, i*i), ShouldBeNil)
+			}
+			So(cache.Put(7, 0), ShouldEqual, ErrDuplicateItem)
+
+			// Keys are spread across every shard.
+			for _, shard := range cache.shards {
+				So(shard.itemList.count, ShouldBeGreaterThan, 0)
+			}
+
+			val, ok := cache.Get(7)
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 49)
+			So(cache.Remove(7), ShouldBeNil)
+			_, ok = cache.Get(7)
+			So(ok, ShouldBeFalse)
+			So(cache.Close(), ShouldBeNil)
+		})
+
+		Convey("Given a full shard, Put evicts from that shard and reports it", func() {
+			cache, err := NewSharded[int, int](2, 2)
+			So(err, ShouldBeNil)
+
+			evicted := 0
+			cache.OnEvict(func(key, item int, reason EvictionReason) {
+				evicted++
+			})
+
+			for i := 0; i < 10; i++ {
+				So(cache.Put(i, i), ShouldBeNil)
+			}
+			So(evicted, ShouldEqual, 10-cache.shards[0].itemList.count-cache.shards[1].itemList.count)
+		})
+	})
+}
+
+// TestConcurrentAccess is most useful under 'go test -race'.
+func TestConcurrentAccess(t *testing.T) {
+	Convey("Concurrent access tests", t, func() {
+		single, err := New[int, int](64)
+		So(err, ShouldBeNil)
+		sharded, err := NewSharded[int, int](8, 64)
+		So(err, ShouldBeNil)
+
+		caches := map[string]interface {
+			Put(int, int) error
+			Get(int) (int, bool)
+			Remove(int) error
+		}{
+			"single":  single,
+			"sharded": sharded,
+		}
+
+		for _, cache := range caches {
+			wg := sync.WaitGroup{}
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < 1000; i++ {
+						key := (i * (g + 1)) % 128
+						switch i % 3 {
+						case 0:
+							_ = cache.Put(key, i)
+						case 1:
+							_, _ = cache.Get(key)
+						case 2:
+							_ = cache.Remove(key)
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+		}
+
+		So(single.itemList.count, ShouldBeLessThanOrEqualTo, 64)
+		So(len(single.itemMap), ShouldEqual, single.itemList.count)
+		for _, shard := range sharded.shards {
+			So(len(shard.itemMap), ShouldEqual, shard.itemList.count)
+		}
+	})
+}
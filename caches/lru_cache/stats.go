@@ -0,0 +1,156 @@
+package lru_cache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// evictionReasons lists every EvictionReason, in the order they are reported.
+var evictionReasons = []EvictionReason{EvictedCapacity, EvictedRemoved, EvictedExpired, EvictedReplaced}
+
+// counters are the cache's running totals. They are atomic, so that they may
+// be read without the cache lock.
+type counters struct {
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	puts       atomic.Uint64
+	duplicates atomic.Uint64
+	// evictions is indexed by EvictionReason.
+	evictions [EvictedReplaced + 1]atomic.Uint64
+}
+
+// Stats is a snapshot of a cache's counters and size.
+type Stats struct {
+	// Hits and Misses count lookups of the cache itself by Get and GetOrLoad;
+	// items loaded from a store or loader after a miss count as misses.
+	Hits   uint64
+	Misses uint64
+	// Puts counts successful Puts and Sets; DuplicatePuts counts Puts rejected
+	// with ErrDuplicateItem.
+	Puts          uint64
+	DuplicatePuts uint64
+	// Evictions counts the items that left the cache, by reason.
+	Evictions map[EvictionReason]uint64
+	// Size is the number of cached items, and Cost is their total cost.
+	Size     int
+	Cost     int64
+	Capacity int
+	// MaxCost is zero if the cache's cost is unbounded.
+	MaxCost int64
+}
+
+// HitRatio returns the fraction of lookups that were hits, or zero if there were none.
+func (stats Stats) HitRatio() float64 {
+	lookups := stats.Hits + stats.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(stats.Hits) / float64(lookups)
+}
+
+// add accumulates other's counters and sizes into stats.
+func (stats *Stats) add(other Stats) {
+	stats.Hits += other.Hits
+	stats.Misses += other.Misses
+	stats.Puts += other.Puts
+	stats.DuplicatePuts += other.DuplicatePuts
+	for reason, n := range other.Evictions {
+		stats.Evictions[reason] += n
+	}
+	stats.Size += other.Size
+	stats.Cost += other.Cost
+	stats.Capacity += other.Capacity
+	stats.MaxCost += other.MaxCost
+}
+
+// Stats returns a snapshot of the cache's counters and size. The counters are
+// read individually, so a snapshot taken during concurrent operations may be
+// slightly inconsistent.
+func (cache *Cache[K, V]) Stats() Stats {
+	stats := Stats{
+		Hits:          cache.counters.hits.Load(),
+		Misses:        cache.counters.misses.Load(),
+		Puts:          cache.counters.puts.Load(),
+		DuplicatePuts: cache.counters.duplicates.Load(),
+		Evictions:     make(map[EvictionReason]uint64, len(evictionReasons)),
+		MaxCost:       cache.maxCost,
+	}
+	for _, reason := range evictionReasons {
+		stats.Evictions[reason] = cache.counters.evictions[reason].Load()
+	}
+
+	cache.mu.RLock()
+	stats.Size = cache.itemList.count
+	stats.Cost = cache.cost
+	stats.Capacity = cache.capacity
+	cache.mu.RUnlock()
+
+	return stats
+}
+
+// Stats returns the sum of every shard's Stats.
+func (sharded *ShardedCache[K, V]) Stats() Stats {
+	stats := Stats{
+		Evictions: make(map[EvictionReason]uint64, len(evictionReasons)),
+	}
+	for _, shard := range sharded.shards {
+		stats.add(shard.Stats())
+	}
+	return stats
+}
+
+// StatsSource is implemented by Cache and ShardedCache.
+type StatsSource interface {
+	Stats() Stats
+}
+
+// NewMetricsHandler returns an http.Handler that serves source's Stats in the
+// Prometheus text exposition format, with metric names prefixed by "lru_cache_".
+func NewMetricsHandler(source StatsSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = writeMetrics(w, source.Stats())
+	})
+}
+
+// writeMetrics writes stats to w in the Prometheus text exposition format.
+func writeMetrics(w io.Writer, stats Stats) error {
+	metrics := []struct {
+		name, kind, help string
+		value            any
+	}{
+		{"hits_total", "counter", "Lookups that found a cached item.", stats.Hits},
+		{"misses_total", "counter", "Lookups that did not find a cached item.", stats.Misses},
+		{"puts_total", "counter", "Items successfully put or set.", stats.Puts},
+		{"duplicate_puts_total", "counter", "Puts rejected because the key was already cached.", stats.DuplicatePuts},
+		{"evictions_total", "counter", "Items that left the cache, by reason.", nil},
+		{"items", "gauge", "Number of cached items.", stats.Size},
+		{"cost", "gauge", "Total cost of the cached items.", stats.Cost},
+		{"capacity", "gauge", "Maximum number of cached items.", stats.Capacity},
+		{"max_cost", "gauge", "Maximum total cost of the cached items, or zero if unbounded.", stats.MaxCost},
+	}
+
+	for _, metric := range metrics {
+		name := "lru_cache_" + metric.name
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, metric.help, name, metric.kind); err != nil {
+			return err
+		}
+
+		if metric.value != nil {
+			if _, err := fmt.Fprintf(w, "%s %d\n", name, metric.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, reason := range evictionReasons {
+			if _, err := fmt.Fprintf(w, "%s{reason=%q} %d\n", name, reason, stats.Evictions[reason]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package lru_cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStats(t *testing.T) {
+	Convey("Stats tests", t, func() {
+		clock := newFakeClock()
+		cache, err := New[string, int](2, WithClock(clock))
+		So(err, ShouldBeNil)
+
+		Convey("Given a new cache, its stats are zero", func() {
+			stats := cache.Stats()
+			So(stats.Hits+stats.Misses+stats.Puts+stats.DuplicatePuts, ShouldEqual, 0)
+			So(stats.Evictions, ShouldHaveLength, len(evictionReasons))
+			So(stats.Capacity, ShouldEqual, 2)
+			So(stats.HitRatio(), ShouldEqual, 0)
+		})
+
+		Convey("Given cache operations, they are counted", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+			So(cache.Put("a", 2), ShouldEqual, ErrDuplicateItem)
+			So(cache.PutWithTTL("b", 2, time.Minute), ShouldBeNil)
+			_, _ = cache.Get("a")
+			_, _ = cache.Get("a")
+			_, _ = cache.Get("c")
+			So(cache.Put("c", 3), ShouldBeNil) // evicts b
+			So(cache.Remove("c"), ShouldBeNil)
+			So(cache.PutWithTTL("d", 4, time.Minute), ShouldBeNil)
+			clock.Advance(time.Minute)
+			_, _ = cache.Get("d") // expired
+
+			stats := cache.Stats()
+			So(stats.Hits, ShouldEqual, 2)
+			So(stats.Misses, ShouldEqual, 2)
+			So(stats.HitRatio(), ShouldEqual, 0.5)
+			So(stats.Puts, ShouldEqual, 4)
+			So(stats.DuplicatePuts, ShouldEqual, 1)
+			So(stats.Evictions[EvictedCapacity], ShouldEqual, 1)
+			So(stats.Evictions[EvictedRemoved], ShouldEqual, 1)
+			So(stats.Evictions[EvictedExpired], ShouldEqual, 1)
+			So(stats.Evictions[EvictedReplaced], ShouldEqual, 0)
+			So(stats.Size, ShouldEqual, 1)
+			So(stats.Cost, ShouldEqual, 1)
+		})
+
+		Convey("Given a sharded cache, its stats are summed across shards", func() {
+			sharded, err := NewSharded[int, int](4, 400)
+			So(err, ShouldBeNil)
+			for i := 0; i < 10; i++ {
+				So(sharded.Put(i, i), ShouldBeNil)
+				_, _ = sharded.Get(i)
+			}
+
+			stats := sharded.Stats()
+			So(stats.Puts, ShouldEqual, 10)
+			So(stats.Hits, ShouldEqual, 10)
+			So(stats.Size, ShouldEqual, 10)
+			So(stats.Capacity, ShouldEqual, 400)
+		})
+
+		Convey("Given a metrics handler, it serves the Prometheus text format", func() {
+			So(cache.Put("a", 1), ShouldBeNil)
+			_, _ = cache.Get("a")
+			So(cache.Remove("a"), ShouldBeNil)
+
+			recorder := httptest.NewRecorder()
+			NewMetricsHandler(cache).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Header().Get("Content-Type"), ShouldStartWith, "text/plain; version=0.0.4")
+			body := recorder.Body.String()
+			So(body, ShouldContainSubstring, "# TYPE lru_cache_hits_total counter\nlru_cache_hits_total 1\n")
+			So(body, ShouldContainSubstring, "lru_cache_puts_total 1\n")
+			So(body, ShouldContainSubstring, `lru_cache_evictions_total{reason="removed"} 1`+"\n")
+			So(body, ShouldContainSubstring, `lru_cache_evictions_total{reason="capacity"} 0`+"\n")
+			So(body, ShouldContainSubstring, "# TYPE lru_cache_items gauge\nlru_cache_items 0\n")
+			So(body, ShouldContainSubstring, "lru_cache_capacity 2\n")
+		})
+	})
+}
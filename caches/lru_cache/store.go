@@ -0,0 +1,332 @@
+package lru_cache
+
+import (
+	"errors"
+	"sync"
+)
+
+// Store is a backing store, such as postgres or minio, injected via WithStore.
+type Store[K comparable, V any] interface {
+	// Load returns the value stored under key, or ErrItemNotFound.
+	Load(key K) (V, error)
+	// Save stores value under key, overwriting any existing value.
+	Save(key K, value V) error
+	// Delete removes the value stored under key, or returns ErrItemNotFound.
+	Delete(key K) error
+}
+
+// StoreMode describes how a Cache reads from and writes to its Store.
+// ReadThrough may be combined with either write mode, e.g. ReadThrough|WriteThrough.
+type StoreMode int
+
+const (
+	// ReadThrough loads and caches items from the store when Get misses.
+	ReadThrough StoreMode = 1 << iota
+	// WriteThrough saves items to the store on Put, and deletes them on Remove,
+	// before the cache is updated.
+	WriteThrough
+	// WriteBehind queues saves and deletes, which are applied to the store
+	// by a background goroutine. See Flush and Close.
+	WriteBehind
+)
+
+func (mode StoreMode) valid() bool {
+	return mode != 0 &&
+		mode&^(ReadThrough|WriteThrough|WriteBehind) == 0 &&
+		mode&(WriteThrough|WriteBehind) != WriteThrough|WriteBehind
+}
+
+// saveToStore writes the item to the store per the cache's StoreMode.
+func (cache *Cache[K, V]) saveToStore(key K, item V) error {
+	switch {
+	case cache.storeMode&WriteThrough != 0:
+		return cache.store.Save(key, item)
+	case cache.writeBehind != nil:
+		return cache.writeBehind.enqueue(key, writeOp[V]{value: item})
+	}
+	return nil
+}
+
+// deleteFromStore deletes the key from the store per the cache's StoreMode.
+// Deleted is true if the store is known to have held the key.
+func (cache *Cache[K, V]) deleteFromStore(key K) (deleted bool, err error) {
+	switch {
+	case cache.storeMode&WriteThrough != 0:
+		err = cache.store.Delete(key)
+		if errors.Is(err, ErrItemNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	case cache.writeBehind != nil:
+		return false, cache.writeBehind.enqueue(key, writeOp[V]{delete: true})
+	}
+	return false, nil
+}
+
+// loadFromStore loads the item from the store and caches it. Pending
+// WriteBehind writes are consulted first, since the store is not yet current.
+// Store errors are reported as a miss.
+func (cache *Cache[K, V]) loadFromStore(key K) (item V, exists bool) {
+	item, exists, _ = cache.tryLoadFromStore(key)
+	return
+}
+
+// tryLoadFromStore loads the item per loadFromStore, but returns any store error
+// other than ErrItemNotFound, which is reported as a miss.
+func (cache *Cache[K, V]) tryLoadFromStore(key K) (item V, exists bool, err error) {
+	if cache.writeBehind != nil {
+		if op, ok := cache.writeBehind.lookup(key); ok {
+			if op.delete {
+				return
+			}
+			item, exists = op.value, true
+		}
+	}
+
+	if !exists {
+		if item, err = cache.store.Load(key); err != nil {
+			var zero V
+			if errors.Is(err, ErrItemNotFound) {
+				err = nil
+			}
+			return zero, false, err
+		}
+	}
+
+	return cache.addLoaded(key, item), true, nil
+}
+
+// Flush blocks until all pending WriteBehind writes have been applied to the
+// store, and returns the first error encountered since the last Flush.
+// Flush is a no-op in other modes.
+func (cache *Cache[K, V]) Flush() error {
+	if cache.writeBehind == nil {
+		return nil
+	}
+	return cache.writeBehind.flush()
+}
+
+// writeOp is a pending WriteBehind save, or delete.
+type writeOp[V any] struct {
+	value  V
+	delete bool
+}
+
+// writeBehindQueue coalesces writes per key and applies them to the store
+// from a single goroutine, in the order keys were first queued.
+type writeBehindQueue[K comparable, V any] struct {
+	store Store[K, V]
+	mu    sync.Mutex
+	// drained is broadcast when there are no pending or in-flight writes.
+	drained  *sync.Cond
+	pending  map[K]writeOp[V]
+	order    []K
+	inflight map[K]writeOp[V]
+	// err is the first write error since the last flush.
+	err    error
+	closed bool
+	// wake signals the worker that writes are pending; done is closed when it exits.
+	wake chan struct{}
+	done chan struct{}
+}
+
+func newWriteBehindQueue[K comparable, V any](store Store[K, V]) *writeBehindQueue[K, V] {
+	q := &writeBehindQueue[K, V]{
+		store:   store,
+		pending: make(map[K]writeOp[V]),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	q.drained = sync.NewCond(&q.mu)
+
+	go q.run()
+
+	return q
+}
+
+// enqueue queues the op, replacing any pending op for the same key.
+// Once the queue is closed, ops are applied synchronously instead.
+func (q *writeBehindQueue[K, V]) enqueue(key K, op writeOp[V]) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return q.apply(key, op)
+	}
+	defer q.mu.Unlock()
+
+	if _, ok := q.pending[key]; !ok {
+		q.order = append(q.order, key)
+	}
+	q.pending[key] = op
+
+	// The wake is sent while locked so that close cannot close the channel beneath it.
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// enqueueMany queues the ops per enqueue, all at once, and returns true, unless
+// the queue is closed, in which case it queues none of them and returns false.
+func (q *writeBehindQueue[K, V]) enqueueMany(ops []keyedOp[K, V]) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+	for _, o := range ops {
+		if _, ok := q.pending[o.key]; !ok {
+			q.order = append(q.order, o.key)
+		}
+		q.pending[o.key] = o.op
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// lookup returns the most recent op for key that has not yet been applied.
+func (q *writeBehindQueue[K, V]) lookup(key K) (op writeOp[V], ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if op, ok = q.pending[key]; ok {
+		return
+	}
+	op, ok = q.inflight[key]
+	return
+}
+
+func (q *writeBehindQueue[K, V]) run() {
+	defer close(q.done)
+
+	for range q.wake {
+		q.drain()
+	}
+}
+
+// drain applies all pending ops.
+func (q *writeBehindQueue[K, V]) drain() {
+	q.mu.Lock()
+	batch, order := q.pending, q.order
+	q.pending, q.order = make(map[K]writeOp[V]), nil
+	q.inflight = batch
+	q.mu.Unlock()
+
+	for _, key := range order {
+		err := q.apply(key, batch[key])
+		if err != nil {
+			q.mu.Lock()
+			if q.err == nil {
+				q.err = err
+			}
+			q.mu.Unlock()
+		}
+	}
+
+	q.mu.Lock()
+	q.inflight = nil
+	if len(q.pending) == 0 {
+		q.drained.Broadcast()
+	}
+	q.mu.Unlock()
+}
+
+func (q *writeBehindQueue[K, V]) apply(key K, op writeOp[V]) error {
+	if !op.delete {
+		return q.store.Save(key, op.value)
+	}
+	if err := q.store.Delete(key); err != nil && !errors.Is(err, ErrItemNotFound) {
+		return err
+	}
+	return nil
+}
+
+// flush waits until all queued ops are applied and returns the first error since the last flush.
+func (q *writeBehindQueue[K, V]) flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.pending) > 0 || q.inflight != nil {
+		q.drained.Wait()
+	}
+
+	err := q.err
+	q.err = nil
+
+	return err
+}
+
+// close stops the worker once all queued ops are applied.
+func (q *writeBehindQueue[K, V]) close() error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.wake)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+
+	return q.flush()
+}
+
+// MemoryStore is a Store backed by a map, e.g. for testing.
+type MemoryStore[K comparable, V any] struct {
+	mu    sync.RWMutex
+	items map[K]V
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore[K comparable, V any]() *MemoryStore[K, V] {
+	return &MemoryStore[K, V]{
+		items: make(map[K]V),
+	}
+}
+
+// Load returns the value stored under key, or ErrItemNotFound.
+func (store *MemoryStore[K, V]) Load(key K) (value V, err error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	value, ok := store.items[key]
+	if !ok {
+		err = ErrItemNotFound
+	}
+	return
+}
+
+// Save stores value under key.
+func (store *MemoryStore[K, V]) Save(key K, value V) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.items[key] = value
+	return nil
+}
+
+// Delete removes the value stored under key, or returns ErrItemNotFound.
+func (store *MemoryStore[K, V]) Delete(key K) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.items[key]; !ok {
+		return ErrItemNotFound
+	}
+	delete(store.items, key)
+	return nil
+}
+
+// Len returns the number of stored values.
+func (store *MemoryStore[K, V]) Len() int {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return len(store.items)
+}
@@ -0,0 +1,200 @@
+package lru_cache
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errStoreDown error = errors.New("store down")
+
+// failingStore fails every operation.
+type failingStore struct{}
+
+func (failingStore) Load(key string) (int, error)     { return 0, errStoreDown }
+func (failingStore) Save(key string, value int) error { return errStoreDown }
+func (failingStore) Delete(key string) error          { return errStoreDown }
+
+func TestStoreModes(t *testing.T) {
+	Convey("Store-backed cache tests", t, func() {
+		store := NewMemoryStore[string, int]()
+
+		Convey("Given a ReadThrough cache, Get loads missing items from the store", func() {
+			So(store.Save("abc", 1), ShouldBeNil)
+			cache, err := New[string, int](1, WithStore[string, int](store, ReadThrough))
+			So(err, ShouldBeNil)
+
+			val, ok := cache.Get("abc")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+			// The loaded item is now cached.
+			So(cache.itemList.head.key, ShouldEqual, "abc")
+
+			_, ok = cache.Get("missing")
+			So(ok, ShouldBeFalse)
+
+			// Puts are not written in ReadThrough-only mode.
+			So(cache.Put("def", 2), ShouldBeNil)
+			_, err = store.Load("def")
+			So(err, ShouldBeError, ErrItemNotFound)
+
+			// Evicted items are reloaded.
+			val, ok = cache.Get("abc")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+		})
+
+		Convey("Given a WriteThrough cache, Put and Remove update the store", func() {
+			cache, err := New[string, int](1, WithStore[string, int](store, ReadThrough|WriteThrough))
+			So(err, ShouldBeNil)
+
+			So(cache.Put("abc", 1), ShouldBeNil)
+			val, err := store.Load("abc")
+			So(err, ShouldBeNil)
+			So(val, ShouldEqual, 1)
+
+			// Evict abc, then remove it from the store alone.
+			So(cache.Put("def", 2), ShouldBeNil)
+			So(cache.Remove("abc"), ShouldBeNil)
+			_, err = store.Load("abc")
+			So(err, ShouldBeError, ErrItemNotFound)
+			So(cache.Remove("abc"), ShouldEqual, ErrItemNotFound)
+
+			So(cache.Remove("def"), ShouldBeNil)
+			So(store.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Given a WriteThrough cache whose store fails, Put does not cache the item", func() {
+			cache, err := New[string, int](1, WithStore[string, int](failingStore{}, WriteThrough))
+			So(err, ShouldBeNil)
+
+			So(cache.Put("abc", 1), ShouldBeError, errStoreDown)
+			_, ok := cache.Get("abc")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Given a WriteBehind cache, writes reach the store once flushed", func() {
+			cache, err := New[string, int](2, WithStore[string, int](store, ReadThrough|WriteBehind))
+			So(err, ShouldBeNil)
+
+			for i, key := range []string{"a", "b", "c", "d"} {
+				So(cache.Put(key, i), ShouldBeNil)
+			}
+			So(cache.Remove("d"), ShouldBeNil)
+
+			// Evicted items are readable before their writes are applied.
+			val, ok := cache.Get("a")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 0)
+
+			So(cache.Flush(), ShouldBeNil)
+			So(store.Len(), ShouldEqual, 3)
+			_, err = store.Load("d")
+			So(err, ShouldBeError, ErrItemNotFound)
+
+			So(cache.Put("e", 4), ShouldBeNil)
+			So(cache.Close(), ShouldBeNil)
+			So(store.Len(), ShouldEqual, 4)
+		})
+
+		Convey("Given a WriteBehind cache whose store fails, Flush returns the error", func() {
+			cache, err := New[string, int](1, WithStore[string, int](failingStore{}, WriteBehind))
+			So(err, ShouldBeNil)
+
+			So(cache.Put("abc", 1), ShouldBeNil)
+			So(cache.Flush(), ShouldBeError, errStoreDown)
+			So(cache.Flush(), ShouldBeNil)
+			So(cache.Close(), ShouldBeNil)
+		})
+
+		Convey("Invalid store options are rejected", func() {
+			_, err := New[string, int](1, WithStore[string, int](store, WriteThrough|WriteBehind))
+			So(err, ShouldBeError, ErrInvalidOption)
+			_, err = New[string, int](1, WithStore[string, int](store, 0))
+			So(err, ShouldBeError, ErrInvalidOption)
+			// The store's types must match the cache's.
+			_, err = New[int, int](1, WithStore[string, int](store, ReadThrough))
+			So(err, ShouldBeError, ErrInvalidOption)
+		})
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	Convey("FileStore tests", t, func() {
+		type record struct {
+			Name  string
+			Count int
+		}
+
+		dir := t.TempDir()
+		store, err := NewFileStore[string, record](dir)
+		So(err, ShouldBeNil)
+
+		Convey("Saved values are loaded and deleted", func() {
+			_, err := store.Load("a/b")
+			So(err, ShouldBeError, ErrItemNotFound)
+
+			So(store.Save("a/b", record{"x", 1}), ShouldBeNil)
+			So(store.Save("a/b", record{"x", 2}), ShouldBeNil)
+			So(store.Save("..", record{"y", 3}), ShouldBeNil)
+
+			val, err := store.Load("a/b")
+			So(err, ShouldBeNil)
+			So(val, ShouldResemble, record{"x", 2})
+			val, err = store.Load("..")
+			So(err, ShouldBeNil)
+			So(val, ShouldResemble, record{"y", 3})
+
+			So(store.Delete("a/b"), ShouldBeNil)
+			So(store.Delete("a/b"), ShouldBeError, ErrItemNotFound)
+		})
+
+		Convey("Long keys are stored under names of a fixed length", func() {
+			key := strings.Repeat("long/key?", 100)
+			So(store.Save(key, record{"l", 5}), ShouldBeNil)
+			val, err := store.Load(key)
+			So(err, ShouldBeNil)
+			So(val, ShouldResemble, record{"l", 5})
+
+			_, err = store.Load(key + "!")
+			So(err, ShouldBeError, ErrItemNotFound)
+		})
+
+		Convey("Keys whose file names collide are told apart", func() {
+			// A collision is simulated by moving a's file to b's name.
+			So(store.Save("a", record{"a", 1}), ShouldBeNil)
+			aPath, err := store.path("a")
+			So(err, ShouldBeNil)
+			bPath, err := store.path("b")
+			So(err, ShouldBeNil)
+			So(os.Rename(aPath, bPath), ShouldBeNil)
+
+			_, err = store.Load("b")
+			So(err, ShouldBeError, ErrItemNotFound)
+			So(store.Delete("b"), ShouldBeError, ErrItemNotFound)
+			So(store.Save("b", record{"b", 2}), ShouldBeError, ErrKeyCollision)
+
+			So(os.Rename(bPath, aPath), ShouldBeNil)
+			val, err := store.Load("a")
+			So(err, ShouldBeNil)
+			So(val, ShouldResemble, record{"a", 1})
+		})
+
+		Convey("Values survive a new FileStore over the same directory", func() {
+			cache, err := New[string, record](1, WithStore[string, record](store, ReadThrough|WriteThrough))
+			So(err, ShouldBeNil)
+			So(cache.Put("abc", record{"z", 4}), ShouldBeNil)
+
+			reopened, err := NewFileStore[string, record](dir)
+			So(err, ShouldBeNil)
+			cache, err = New[string, record](1, WithStore[string, record](reopened, ReadThrough))
+			So(err, ShouldBeNil)
+			val, ok := cache.Get("abc")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldResemble, record{"z", 4})
+		})
+	})
+}
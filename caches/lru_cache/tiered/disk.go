@@ -0,0 +1,633 @@
+package tiered
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"lru_cache"
+)
+
+const (
+	// DefaultSegmentSize is the size at which a DiskCache starts a new segment file.
+	DefaultSegmentSize = 64 << 20
+	// DefaultMaxEntries is the default maximum number of entries in a DiskCache,
+	// in addition to its maximum size.
+	DefaultMaxEntries = 1 << 16
+
+	segmentSuffix = ".seg"
+	// headerSize is that of a record's checksum, op, and key and value lengths.
+	headerSize = 4 + 1 + 4 + 4
+	// maxKeySize bounds the key length read from a header, such that a corrupt
+	// header is detected before its key is allocated.
+	maxKeySize = 1 << 16
+)
+
+// A record's op is whether it puts or deletes its key.
+const (
+	opPut byte = iota + 1
+	opDelete
+)
+
+var (
+	// ErrCorruptSegment is returned by OpenDiskCache if a record is invalid other
+	// than at the end of the newest segment, which is truncated instead, since it
+	// may have been partially written by a crash.
+	ErrCorruptSegment error = errors.New("tiered: corrupt segment file")
+	// ErrClosed is returned by a closed DiskCache.
+	ErrClosed error = errors.New("tiered: cache closed")
+)
+
+// location is the position of an entry's record in the segment files.
+type location struct {
+	segment int
+	// offset is that of the record's value, whose length is size.
+	offset int64
+	size   int
+	// record is the length of the entire record.
+	record int64
+}
+
+// DiskCache is a least-recently-used cache of byte values on disk. Entries are
+// appended to segment files in a directory as records that put or delete a key,
+// and indexed in memory by a lru_cache.Cache, which bounds their total size.
+//
+// When the cache is opened, its index is rebuilt by replaying the segments in
+// order, so entries are restored in the order they were put. Segments are deleted
+// once none of their records are live, and compacted once most of their bytes are
+// garbage; compaction rewrites the live entries from least to most recently used,
+// which also persists the order in which they were last read.
+//
+// Writes are buffered until they are read, Sync or Close is called, or the active
+// segment is full. DiskCache is safe for concurrent use, but a directory must only
+// be opened by one DiskCache at a time.
+type DiskCache struct {
+	dir         string
+	maxBytes    int64
+	segmentSize int64
+
+	mu    sync.Mutex
+	index *lru_cache.Cache[string, location]
+	// files holds the open segments, of which the newest is active.
+	files  map[int]*os.File
+	active int
+	w      *bufio.Writer
+	offset int64
+	// live is the length of the live records of each segment, and liveBytes their
+	// sum; fileBytes is the length of every segment, including garbage.
+	live      map[int]int64
+	liveBytes int64
+	fileBytes int64
+	// err is the first error of writing a tombstone while evicting an entry,
+	// which is returned by the next operation that writes.
+	err error
+	// replaying is true while the index is rebuilt, such that evicted entries are
+	// not written as tombstones; evictedOnReplay forces a compaction instead.
+	replaying       bool
+	evictedOnReplay bool
+	closed          bool
+}
+
+// DiskOption configures optional DiskCache behavior when passed to OpenDiskCache.
+type DiskOption func(*diskOptions)
+
+type diskOptions struct {
+	segmentSize int64
+	maxEntries  int
+}
+
+// WithSegmentSize sets the size at which a new segment file is started; the
+// default is DefaultSegmentSize. Records larger than it get a segment of their own.
+func WithSegmentSize(size int64) DiskOption {
+	return func(o *diskOptions) {
+		o.segmentSize = size
+	}
+}
+
+// WithMaxEntries sets the maximum number of entries; the default is DefaultMaxEntries.
+func WithMaxEntries(n int) DiskOption {
+	return func(o *diskOptions) {
+		o.maxEntries = n
+	}
+}
+
+// OpenDiskCache opens the cache in dir, which is created if it does not exist,
+// and whose records total at most maxBytes. Existing segments are replayed to
+// rebuild the cache, evicting the least recently put entries if it is now smaller.
+func OpenDiskCache(dir string, maxBytes int64, opts ...DiskOption) (*DiskCache, error) {
+	o := diskOptions{
+		segmentSize: DefaultSegmentSize,
+		maxEntries:  DefaultMaxEntries,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if maxBytes <= 0 || o.segmentSize <= 0 || o.maxEntries <= 0 {
+		return nil, lru_cache.ErrInvalidOption
+	}
+
+	index, err := lru_cache.New[string, location](o.maxEntries,
+		lru_cache.WithMaxCost(maxBytes),
+		lru_cache.WithCost(func(loc location) int64 { return loc.record }),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	d := &DiskCache{
+		dir:         dir,
+		maxBytes:    maxBytes,
+		segmentSize: o.segmentSize,
+		index:       index,
+		files:       make(map[int]*os.File),
+		live:        make(map[int]int64),
+	}
+	index.OnEvict(d.evicted)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.open(); err != nil {
+		_ = d.closeFiles()
+		return nil, err
+	}
+	return d, nil
+}
+
+// open replays the segments and then prepares the newest for appending.
+// The caller must hold d.mu.
+func (d *DiskCache) open() error {
+	ids, err := d.segmentIDs()
+	if err != nil {
+		return err
+	}
+
+	d.replaying = true
+	for i, id := range ids {
+		f, err := os.OpenFile(d.segmentPath(id), os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		d.files[id] = f
+
+		end, err := d.replay(id, f)
+		if errors.Is(err, ErrCorruptSegment) && i == len(ids)-1 {
+			err = f.Truncate(end)
+		}
+		if err != nil {
+			return err
+		}
+		d.fileBytes += end
+	}
+	d.replaying = false
+
+	if len(ids) == 0 {
+		return d.startSegment(1)
+	}
+
+	d.active = ids[len(ids)-1]
+	if d.offset, err = d.files[d.active].Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	d.w = bufio.NewWriter(d.files[d.active])
+
+	if d.evictedOnReplay {
+		// Entries evicted while replaying would otherwise be restored again.
+		return d.compact()
+	}
+	return d.maintain()
+}
+
+// segmentIDs returns the IDs of the directory's segments, in ascending order.
+func (d *DiskCache) segmentIDs() ([]int, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentSuffix)
+		if !ok {
+			continue
+		}
+		if id, err := strconv.Atoi(name); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (d *DiskCache) segmentPath(id int) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%08d%s", id, segmentSuffix))
+}
+
+// replay applies the segment's records to the index, and returns the end of its
+// last valid record. The caller must hold d.mu.
+func (d *DiskCache) replay(id int, f *os.File) (end int64, err error) {
+	r := bufio.NewReader(io.NewSectionReader(f, 0, 1<<62))
+	for {
+		op, key, loc, err := readRecord(r)
+		if err == io.EOF {
+			return end, nil
+		}
+		if err != nil {
+			return end, err
+		}
+
+		switch op {
+		case opPut:
+			loc.segment = id
+			loc.offset += end
+			d.live[id] += loc.record
+			d.liveBytes += loc.record
+			_ = d.index.Set(key, loc)
+		case opDelete:
+			_ = d.index.Remove(key)
+		}
+		end += loc.record
+	}
+}
+
+// readRecord reads and verifies the next record, whose value is discarded, and
+// returns its op, key and location relative to the record. It returns io.EOF at
+// the end of the segment, or ErrCorruptSegment if the record is partial or invalid.
+func readRecord(r *bufio.Reader) (op byte, key string, loc location, err error) {
+	var header [headerSize]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = ErrCorruptSegment
+		}
+		return
+	}
+
+	op = header[4]
+	keySize := binary.LittleEndian.Uint32(header[5:])
+	valueSize := binary.LittleEndian.Uint32(header[9:])
+	if (op != opPut && op != opDelete) || keySize > maxKeySize {
+		err = ErrCorruptSegment
+		return
+	}
+
+	h := crc32.NewIEEE()
+	h.Write(header[4:])
+	keyBytes := make([]byte, keySize)
+	if _, err = io.ReadFull(r, keyBytes); err != nil {
+		err = ErrCorruptSegment
+		return
+	}
+	h.Write(keyBytes)
+	if _, err = io.CopyN(h, r, int64(valueSize)); err != nil {
+		err = ErrCorruptSegment
+		return
+	}
+	if h.Sum32() != binary.LittleEndian.Uint32(header[0:]) {
+		err = ErrCorruptSegment
+		return
+	}
+
+	loc = location{
+		offset: int64(headerSize + keySize),
+		size:   int(valueSize),
+		record: int64(headerSize + keySize + valueSize),
+	}
+	return op, string(keyBytes), loc, nil
+}
+
+// startSegment creates the segment of the passed ID and makes it active.
+// The caller must hold d.mu.
+func (d *DiskCache) startSegment(id int) error {
+	if d.w != nil {
+		if err := d.w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(d.segmentPath(id), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	d.files[id] = f
+	d.active = id
+	d.offset = 0
+	d.w = bufio.NewWriter(f)
+	return nil
+}
+
+// append writes a record to the active segment, first starting a new one if
+// it is full, and returns the record's location. The caller must hold d.mu.
+func (d *DiskCache) append(op byte, key string, value []byte) (location, error) {
+	record := int64(headerSize + len(key) + len(value))
+	if d.offset > 0 && d.offset+record > d.segmentSize {
+		if err := d.startSegment(d.active + 1); err != nil {
+			return location{}, err
+		}
+	}
+
+	var header [headerSize]byte
+	header[4] = op
+	binary.LittleEndian.PutUint32(header[5:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[9:], uint32(len(value)))
+	h := crc32.NewIEEE()
+	h.Write(header[4:])
+	h.Write([]byte(key))
+	h.Write(value)
+	binary.LittleEndian.PutUint32(header[0:], h.Sum32())
+
+	for _, b := range [][]byte{header[:], []byte(key), value} {
+		if _, err := d.w.Write(b); err != nil {
+			return location{}, err
+		}
+	}
+
+	loc := location{
+		segment: d.active,
+		offset:  d.offset + record - int64(len(value)),
+		size:    len(value),
+		record:  record,
+	}
+	d.offset += record
+	d.fileBytes += record
+	return loc, nil
+}
+
+// evicted is the index's OnEvict callback, which releases the entry's record
+// and, unless it was replaced, appends a tombstone such that the entry is not
+// restored when the cache is reopened. It is called by index operations made
+// while d.mu is held.
+func (d *DiskCache) evicted(key string, loc location, reason lru_cache.EvictionReason) {
+	d.live[loc.segment] -= loc.record
+	d.liveBytes -= loc.record
+
+	switch {
+	case reason == lru_cache.EvictedReplaced:
+	case d.replaying:
+		d.evictedOnReplay = d.evictedOnReplay || reason == lru_cache.EvictedCapacity
+	default:
+		if _, err := d.append(opDelete, key, nil); err != nil && d.err == nil {
+			d.err = err
+		}
+	}
+}
+
+// read returns the value at loc. The caller must hold d.mu.
+func (d *DiskCache) read(loc location) ([]byte, error) {
+	if loc.segment == d.active {
+		if err := d.w.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	value := make([]byte, loc.size)
+	if _, err := d.files[loc.segment].ReadAt(value, loc.offset); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// maintain deletes the oldest segments while none of their records are live, and
+// compacts the cache once most of its bytes are garbage. The caller must hold d.mu.
+//
+// Only the oldest segment may be deleted, because a segment's tombstones are
+// needed for as long as an older segment may hold the records they delete.
+func (d *DiskCache) maintain() error {
+	for {
+		oldest := d.active
+		for id := range d.files {
+			oldest = min(oldest, id)
+		}
+		if oldest == d.active || d.live[oldest] > 0 {
+			break
+		}
+		if err := d.deleteSegment(oldest); err != nil {
+			return err
+		}
+	}
+
+	if d.fileBytes > 2*d.liveBytes && d.fileBytes > max(d.segmentSize, d.maxBytes) {
+		return d.compact()
+	}
+	return nil
+}
+
+// deleteSegment closes and removes the segment. The caller must hold d.mu.
+func (d *DiskCache) deleteSegment(id int) error {
+	f := d.files[id]
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+
+	delete(d.files, id)
+	delete(d.live, id)
+	d.fileBytes -= size
+	return nil
+}
+
+// compact rewrites the live entries, from least to most recently used, to new
+// segments, and then deletes the old ones. The old segments are only deleted
+// once the new ones are synced, such that a crash loses no entries; should it
+// happen before, the rewritten records replace the old ones when replayed.
+// The caller must hold d.mu.
+func (d *DiskCache) compact() error {
+	old := slices.Collect(maps.Keys(d.files))
+	if err := d.startSegment(d.active + 1); err != nil {
+		return err
+	}
+
+	for key, loc := range d.index.Backward() {
+		value, err := d.read(loc)
+		if err != nil {
+			return err
+		}
+		moved, err := d.append(opPut, key, value)
+		if err != nil {
+			return err
+		}
+		d.live[moved.segment] += moved.record
+		d.liveBytes += moved.record
+		// Setting each entry moves it to the front, which preserves their order.
+		_ = d.index.Set(key, moved)
+	}
+
+	if err := d.sync(); err != nil {
+		return err
+	}
+	for _, id := range old {
+		if err := d.deleteSegment(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put adds or replaces the value stored under key, which becomes the most recently
+// used, and evicts the least recently used entries while the cache is over its size.
+// It returns an *lru_cache.ItemTooLargeError if the entry's record alone exceeds it.
+func (d *DiskCache) Put(key string, value []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+	if len(key) > maxKeySize {
+		return fmt.Errorf("tiered: key of %d bytes exceeds %d", len(key), maxKeySize)
+	}
+	if record := int64(headerSize + len(key) + len(value)); record > d.maxBytes {
+		return &lru_cache.ItemTooLargeError{Cost: record, MaxCost: d.maxBytes}
+	}
+
+	loc, err := d.append(opPut, key, value)
+	if err != nil {
+		return err
+	}
+	d.live[loc.segment] += loc.record
+	d.liveBytes += loc.record
+	_ = d.index.Set(key, loc)
+
+	return d.finish()
+}
+
+// finish returns the first error of writing a tombstone, if any, or else maintains
+// the segments. The caller must hold d.mu.
+func (d *DiskCache) finish() error {
+	if err := d.err; err != nil {
+		d.err = nil
+		return err
+	}
+	return d.maintain()
+}
+
+// Get returns the value stored under key, which becomes the most recently used.
+func (d *DiskCache) Get(key string) (value []byte, ok bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil, false, ErrClosed
+	}
+	loc, ok := d.index.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if value, err = d.read(loc); err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Take removes and returns the value stored under key, e.g. to move it to another cache.
+func (d *DiskCache) Take(key string) (value []byte, ok bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil, false, ErrClosed
+	}
+	loc, ok := d.index.Peek(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if value, err = d.read(loc); err != nil {
+		return nil, false, err
+	}
+	_ = d.index.Remove(key)
+
+	return value, true, d.finish()
+}
+
+// Remove removes the value stored under key, and returns false if there was none.
+func (d *DiskCache) Remove(key string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return false, ErrClosed
+	}
+	if d.index.Remove(key) != nil {
+		return false, nil
+	}
+	return true, d.finish()
+}
+
+// Contains returns true if a value is stored under key, without using it.
+func (d *DiskCache) Contains(key string) bool {
+	return d.index.Contains(key)
+}
+
+// Len returns the number of entries.
+func (d *DiskCache) Len() int {
+	return d.index.Len()
+}
+
+// Size returns the total length of the entries' records.
+func (d *DiskCache) Size() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.liveBytes
+}
+
+// Sync writes buffered records to the active segment, and commits it to disk.
+func (d *DiskCache) Sync() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+	return d.sync()
+}
+
+// sync flushes and syncs the active segment. The caller must hold d.mu.
+func (d *DiskCache) sync() error {
+	if err := d.w.Flush(); err != nil {
+		return err
+	}
+	return d.files[d.active].Sync()
+}
+
+// Close syncs and closes the segments. The cache may be reopened with OpenDiskCache.
+func (d *DiskCache) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	err := d.sync()
+	return errors.Join(err, d.closeFiles())
+}
+
+// closeFiles closes the open segments. The caller must hold d.mu.
+func (d *DiskCache) closeFiles() error {
+	var errs []error
+	for _, f := range d.files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,204 @@
+package tiered
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordSize is the length of the record of a one-byte key and a value of size bytes.
+func recordSize(size int) int64 {
+	return int64(headerSize + 1 + size)
+}
+
+func segmentCount(dir string) int {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	return len(matches)
+}
+
+func TestDiskCache(t *testing.T) {
+	Convey("DiskCache tests", t, func() {
+		dir := t.TempDir()
+
+		Convey("Given an invalid size, it is rejected", func() {
+			_, err := OpenDiskCache(dir, 0)
+			So(err, ShouldNotBeNil)
+			_, err = OpenDiskCache(dir, 100, WithSegmentSize(0))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Given puts, gets and removes, values are stored", func() {
+			d, err := OpenDiskCache(dir, 1<<20)
+			So(err, ShouldBeNil)
+			defer d.Close()
+
+			So(d.Put("a", []byte("one")), ShouldBeNil)
+			So(d.Put("b", []byte("two")), ShouldBeNil)
+			So(d.Put("a", []byte("uno")), ShouldBeNil)
+
+			value, ok, err := d.Get("a")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(string(value), ShouldEqual, "uno")
+			So(d.Len(), ShouldEqual, 2)
+			So(d.Size(), ShouldEqual, 2*recordSize(3))
+
+			removed, err := d.Remove("a")
+			So(err, ShouldBeNil)
+			So(removed, ShouldBeTrue)
+			removed, err = d.Remove("a")
+			So(err, ShouldBeNil)
+			So(removed, ShouldBeFalse)
+
+			value, ok, err = d.Take("b")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(string(value), ShouldEqual, "two")
+			So(d.Contains("b"), ShouldBeFalse)
+			So(d.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Given more bytes than fit, the least recently used are evicted", func() {
+			d, err := OpenDiskCache(dir, 3*recordSize(10))
+			So(err, ShouldBeNil)
+			defer d.Close()
+
+			value := make([]byte, 10)
+			for _, key := range []string{"a", "b", "c"} {
+				So(d.Put(key, value), ShouldBeNil)
+			}
+			_, _, _ = d.Get("a")
+			So(d.Put("d", value), ShouldBeNil)
+
+			So(d.Contains("a"), ShouldBeTrue)
+			So(d.Contains("b"), ShouldBeFalse)
+			So(d.Len(), ShouldEqual, 3)
+
+			err = d.Put("e", make([]byte, 100))
+			So(err, ShouldNotBeNil)
+			So(d.Len(), ShouldEqual, 3)
+		})
+
+		Convey("Given a reopened cache, its entries are restored", func() {
+			d, err := OpenDiskCache(dir, 3*recordSize(1), WithSegmentSize(2*recordSize(1)))
+			So(err, ShouldBeNil)
+			for i, key := range []string{"a", "b", "c", "d"} {
+				So(d.Put(key, []byte(strconv.Itoa(i))), ShouldBeNil)
+			}
+			removed, err := d.Remove("c")
+			So(err, ShouldBeNil)
+			So(removed, ShouldBeTrue)
+			So(d.Close(), ShouldBeNil)
+
+			_, _, err = d.Get("b")
+			So(err, ShouldEqual, ErrClosed)
+
+			d, err = OpenDiskCache(dir, 3*recordSize(1), WithSegmentSize(2*recordSize(1)))
+			So(err, ShouldBeNil)
+			defer d.Close()
+
+			// a was evicted and c removed, which tombstones keep from being restored.
+			So(d.Len(), ShouldEqual, 2)
+			So(d.Contains("a"), ShouldBeFalse)
+			So(d.Contains("c"), ShouldBeFalse)
+			value, ok, err := d.Get("d")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(string(value), ShouldEqual, "3")
+
+			// b is the least recently put, so it is evicted first.
+			So(d.Put("e", []byte("4")), ShouldBeNil)
+			So(d.Put("f", []byte("5")), ShouldBeNil)
+			So(d.Contains("b"), ShouldBeFalse)
+			So(d.Contains("d"), ShouldBeTrue)
+		})
+
+		Convey("Given a smaller size when reopened, the oldest entries are evicted for good", func() {
+			d, err := OpenDiskCache(dir, 1<<20)
+			So(err, ShouldBeNil)
+			for _, key := range []string{"a", "b", "c"} {
+				So(d.Put(key, []byte(key)), ShouldBeNil)
+			}
+			So(d.Close(), ShouldBeNil)
+
+			d, err = OpenDiskCache(dir, 2*recordSize(1))
+			So(err, ShouldBeNil)
+			So(d.Contains("a"), ShouldBeFalse)
+			So(d.Close(), ShouldBeNil)
+
+			d, err = OpenDiskCache(dir, 1<<20)
+			So(err, ShouldBeNil)
+			defer d.Close()
+			So(d.Len(), ShouldEqual, 2)
+			So(d.Contains("a"), ShouldBeFalse)
+		})
+
+		Convey("Given a partially written record, the newest segment is truncated", func() {
+			d, err := OpenDiskCache(dir, 1<<20)
+			So(err, ShouldBeNil)
+			So(d.Put("a", []byte("one")), ShouldBeNil)
+			So(d.Put("b", []byte("two")), ShouldBeNil)
+			So(d.Close(), ShouldBeNil)
+
+			path := filepath.Join(dir, "00000001"+segmentSuffix)
+			So(os.Truncate(path, 2*recordSize(3)-1), ShouldBeNil)
+
+			d, err = OpenDiskCache(dir, 1<<20)
+			So(err, ShouldBeNil)
+			So(d.Contains("a"), ShouldBeTrue)
+			So(d.Contains("b"), ShouldBeFalse)
+			So(d.Put("c", []byte("three")), ShouldBeNil)
+			So(d.Close(), ShouldBeNil)
+
+			info, err := os.Stat(path)
+			So(err, ShouldBeNil)
+			So(info.Size(), ShouldEqual, recordSize(3)+recordSize(5))
+		})
+
+		Convey("Given a corrupt record in an older segment, opening fails", func() {
+			d, err := OpenDiskCache(dir, 1<<20, WithSegmentSize(recordSize(3)))
+			So(err, ShouldBeNil)
+			So(d.Put("a", []byte("one")), ShouldBeNil)
+			So(d.Put("b", []byte("two")), ShouldBeNil)
+			So(d.Close(), ShouldBeNil)
+
+			path := filepath.Join(dir, "00000001"+segmentSuffix)
+			data, err := os.ReadFile(path)
+			So(err, ShouldBeNil)
+			data[len(data)-1] ^= 0xff
+			So(os.WriteFile(path, data, 0o644), ShouldBeNil)
+
+			_, err = OpenDiskCache(dir, 1<<20)
+			So(err, ShouldEqual, ErrCorruptSegment)
+		})
+
+		Convey("Given overwritten entries, garbage segments are deleted or compacted", func() {
+			d, err := OpenDiskCache(dir, 4*recordSize(10), WithSegmentSize(2*recordSize(10)))
+			So(err, ShouldBeNil)
+
+			value := make([]byte, 10)
+			for i := 0; i < 100; i++ {
+				So(d.Put(string(rune('a'+i%4)), value), ShouldBeNil)
+			}
+			So(d.Len(), ShouldEqual, 4)
+			So(segmentCount(dir), ShouldBeLessThanOrEqualTo, 4)
+
+			_, _, _ = d.Get("a")
+			So(d.Close(), ShouldBeNil)
+
+			d, err = OpenDiskCache(dir, 4*recordSize(10), WithSegmentSize(2*recordSize(10)))
+			So(err, ShouldBeNil)
+			defer d.Close()
+			So(d.Len(), ShouldEqual, 4)
+			for _, key := range []string{"a", "b", "c", "d"} {
+				value, ok, err := d.Get(key)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+				So(value, ShouldHaveLength, 10)
+			}
+		})
+	})
+}
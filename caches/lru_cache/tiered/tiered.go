@@ -0,0 +1,210 @@
+// Package tiered composes an in-memory lru_cache.Cache with a DiskCache into
+// a two-tier cache. Items evicted from the memory tier (L1) for capacity are
+// demoted to the disk tier (L2), and items found in L2 are promoted back to L1.
+// Since L2 rebuilds itself from its segment files when it is opened, its items
+// survive a restart.
+package tiered
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"lru_cache"
+)
+
+// Option configures optional Cache behavior when passed to New.
+type Option func(*options)
+
+type options struct {
+	// codec and keyFunc are a lru_cache.Codec[V] and a func(K) string, which
+	// New type-asserts.
+	codec   any
+	keyFunc any
+}
+
+// WithCodec sets the codec with which items are stored in L2; the default is
+// lru_cache.GobCodec. New returns lru_cache.ErrInvalidOption if V differs from
+// the cache's item type.
+func WithCodec[V any](codec lru_cache.Codec[V]) Option {
+	return func(o *options) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
+
+// WithKeyFunc sets the function with which keys are converted to L2's string keys,
+// which must be distinct for distinct keys. The default uses string keys as they
+// are, and the fmt representation of other keys. New returns
+// lru_cache.ErrInvalidOption if K differs from the cache's key type.
+func WithKeyFunc[K comparable](fn func(key K) string) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.keyFunc = fn
+		}
+	}
+}
+
+// Cache is a two-tier cache of an L1 lru_cache.Cache and an L2 DiskCache. An item
+// is held by at most one tier: L1 holds the most recently used items, and L2 those
+// that were since evicted from L1 for capacity, until they are used again. Items
+// removed from L1 for any other reason, e.g. that they expired, are not demoted,
+// and demoted items no longer expire. Cache is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	// mu makes moving an item between the tiers atomic.
+	mu            sync.Mutex
+	l1            *lru_cache.Cache[K, V]
+	l2            *DiskCache
+	codec         lru_cache.Codec[V]
+	keyFunc       func(key K) string
+	onDemoteError atomic.Pointer[func(key K, err error)]
+}
+
+// New returns a cache of the passed tiers. It registers l1's OnEvict callback,
+// which must not be replaced, and l1 and l2 should not otherwise be modified
+// other than through the cache.
+func New[K comparable, V any](l1 *lru_cache.Cache[K, V], l2 *DiskCache, opts ...Option) (*Cache[K, V], error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cache := &Cache[K, V]{
+		l1:      l1,
+		l2:      l2,
+		codec:   lru_cache.GobCodec[V]{},
+		keyFunc: defaultKeyFunc[K],
+	}
+	if o.codec != nil {
+		codec, ok := o.codec.(lru_cache.Codec[V])
+		if !ok {
+			return nil, lru_cache.ErrInvalidOption
+		}
+		cache.codec = codec
+	}
+	if o.keyFunc != nil {
+		keyFunc, ok := o.keyFunc.(func(key K) string)
+		if !ok {
+			return nil, lru_cache.ErrInvalidOption
+		}
+		cache.keyFunc = keyFunc
+	}
+
+	l1.OnEvict(cache.demote)
+	return cache, nil
+}
+
+func defaultKeyFunc[K comparable](key K) string {
+	if s, ok := any(key).(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// OnDemoteError registers fn to be called when an item evicted from L1 could
+// not be demoted to L2, and is therefore lost, replacing any previous callback;
+// a nil fn removes it.
+func (cache *Cache[K, V]) OnDemoteError(fn func(key K, err error)) {
+	if fn == nil {
+		cache.onDemoteError.Store(nil)
+		return
+	}
+	cache.onDemoteError.Store(&fn)
+}
+
+// demote is L1's OnEvict callback, which writes items evicted for capacity to L2.
+func (cache *Cache[K, V]) demote(key K, item V, reason lru_cache.EvictionReason) {
+	if reason != lru_cache.EvictedCapacity {
+		return
+	}
+
+	data, err := cache.codec.Marshal(item)
+	if err == nil {
+		err = cache.l2.Put(cache.keyFunc(key), data)
+	}
+	if fn := cache.onDemoteError.Load(); err != nil && fn != nil {
+		(*fn)(key, err)
+	}
+}
+
+// Get returns the item stored under key, from L1 or else from L2, in which case
+// it is promoted to L1. An error is returned if the item could not be read from L2.
+func (cache *Cache[K, V]) Get(key K) (item V, ok bool, err error) {
+	if item, ok = cache.l1.Get(key); ok {
+		return item, true, nil
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	// The item may have been promoted while the lock was awaited.
+	if item, ok = cache.l1.Get(key); ok {
+		return item, true, nil
+	}
+
+	data, ok, err := cache.l2.Take(cache.keyFunc(key))
+	if !ok || err != nil {
+		return item, false, err
+	}
+	if item, err = cache.codec.Unmarshal(data); err != nil {
+		return item, false, err
+	}
+
+	// An item L1 cannot hold, e.g. because it is too large, stays in L2.
+	if err := cache.l1.Set(key, item); err != nil {
+		return item, true, cache.l2.Put(cache.keyFunc(key), data)
+	}
+	return item, true, nil
+}
+
+// Set adds or replaces the item stored under key in L1, and removes any copy of
+// it from L2. Items evicted from L1 as a result are demoted to L2.
+func (cache *Cache[K, V]) Set(key K, item V) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if _, err := cache.l2.Remove(cache.keyFunc(key)); err != nil {
+		return err
+	}
+	return cache.l1.Set(key, item)
+}
+
+// Remove removes the item stored under key from both tiers, and returns
+// false if there was none.
+func (cache *Cache[K, V]) Remove(key K) (bool, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	removed := cache.l1.Remove(key) == nil
+	removedL2, err := cache.l2.Remove(cache.keyFunc(key))
+	return removed || removedL2, err
+}
+
+// Len returns the number of items in both tiers.
+func (cache *Cache[K, V]) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.l1.Len() + cache.l2.Len()
+}
+
+// Close demotes L1's unexpired items to L2, from least to most recently used, and
+// then closes L2, such that the items are restored when it is reopened. L1 is left
+// unchanged, but the cache must not be used after it is closed.
+func (cache *Cache[K, V]) Close() error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for key, item := range cache.l1.Backward() {
+		data, err := cache.codec.Marshal(item)
+		if err == nil {
+			err = cache.l2.Put(cache.keyFunc(key), data)
+		}
+		if err != nil {
+			_ = cache.l2.Close()
+			return err
+		}
+	}
+	return cache.l2.Close()
+}
@@ -0,0 +1,140 @@
+package tiered
+
+import (
+	"errors"
+	"testing"
+
+	"lru_cache"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// failingCodec cannot marshal items.
+type failingCodec struct{}
+
+func (failingCodec) Marshal(item int) ([]byte, error) {
+	return nil, errors.New("cannot marshal")
+}
+
+func (failingCodec) Unmarshal(data []byte) (int, error) {
+	return 0, errors.New("cannot unmarshal")
+}
+
+func newTieredCache(dir string, capacity int, opts ...Option) *Cache[int, string] {
+	l1, err := lru_cache.New[int, string](capacity)
+	So(err, ShouldBeNil)
+	l2, err := OpenDiskCache(dir, 1<<20)
+	So(err, ShouldBeNil)
+	cache, err := New(l1, l2, opts...)
+	So(err, ShouldBeNil)
+	return cache
+}
+
+func TestTieredCache(t *testing.T) {
+	Convey("Tiered cache tests", t, func() {
+		dir := t.TempDir()
+
+		Convey("Given a mismatched option, it is rejected", func() {
+			l1, err := lru_cache.New[int, string](2)
+			So(err, ShouldBeNil)
+			l2, err := OpenDiskCache(dir, 1<<20)
+			So(err, ShouldBeNil)
+			defer l2.Close()
+
+			_, err = New(l1, l2, WithCodec[int](failingCodec{}))
+			So(err, ShouldEqual, lru_cache.ErrInvalidOption)
+			_, err = New(l1, l2, WithKeyFunc(func(key string) string { return key }))
+			So(err, ShouldEqual, lru_cache.ErrInvalidOption)
+		})
+
+		Convey("Given items evicted from L1, they are demoted and promoted back", func() {
+			cache := newTieredCache(dir, 2)
+			defer cache.Close()
+
+			for i, item := range []string{"zero", "one", "two"} {
+				So(cache.Set(i, item), ShouldBeNil)
+			}
+			So(cache.l1.Contains(0), ShouldBeFalse)
+			So(cache.l2.Contains("0"), ShouldBeTrue)
+			So(cache.Len(), ShouldEqual, 3)
+
+			item, ok, err := cache.Get(0)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(item, ShouldEqual, "zero")
+
+			// 0 is promoted to L1, which demotes 1.
+			So(cache.l1.Contains(0), ShouldBeTrue)
+			So(cache.l2.Contains("0"), ShouldBeFalse)
+			So(cache.l2.Contains("1"), ShouldBeTrue)
+			So(cache.Len(), ShouldEqual, 3)
+
+			_, ok, err = cache.Get(3)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Given a set or removed item, it is removed from L2", func() {
+			cache := newTieredCache(dir, 1)
+			defer cache.Close()
+
+			So(cache.Set(0, "zero"), ShouldBeNil)
+			So(cache.Set(1, "one"), ShouldBeNil)
+			So(cache.Set(0, "cero"), ShouldBeNil)
+			So(cache.l2.Contains("0"), ShouldBeFalse)
+			So(cache.Len(), ShouldEqual, 2)
+
+			item, ok, err := cache.Get(0)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(item, ShouldEqual, "cero")
+
+			removed, err := cache.Remove(1)
+			So(err, ShouldBeNil)
+			So(removed, ShouldBeTrue)
+			removed, err = cache.Remove(1)
+			So(err, ShouldBeNil)
+			So(removed, ShouldBeFalse)
+			So(cache.Len(), ShouldEqual, 1)
+		})
+
+		Convey("Given a restart, L2 restores both tiers' items", func() {
+			cache := newTieredCache(dir, 2)
+			for i, item := range []string{"zero", "one", "two", "three"} {
+				So(cache.Set(i, item), ShouldBeNil)
+			}
+			So(cache.Close(), ShouldBeNil)
+
+			cache = newTieredCache(dir, 2)
+			defer cache.Close()
+			So(cache.l1.Len(), ShouldEqual, 0)
+			So(cache.Len(), ShouldEqual, 4)
+
+			for i, want := range []string{"zero", "one", "two", "three"} {
+				item, ok, err := cache.Get(i)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+				So(item, ShouldEqual, want)
+			}
+		})
+
+		Convey("Given an item that cannot be demoted, the error is reported", func() {
+			l1, err := lru_cache.New[int, int](1)
+			So(err, ShouldBeNil)
+			l2, err := OpenDiskCache(dir, 1<<20)
+			So(err, ShouldBeNil)
+			cache, err := New(l1, l2, WithCodec[int](failingCodec{}))
+			So(err, ShouldBeNil)
+			defer l2.Close()
+
+			var failed []int
+			cache.OnDemoteError(func(key int, err error) {
+				failed = append(failed, key)
+			})
+			So(cache.Set(0, 0), ShouldBeNil)
+			So(cache.Set(1, 1), ShouldBeNil)
+			So(failed, ShouldResemble, []int{0})
+			So(cache.Len(), ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,245 @@
+package lru_cache
+
+import "hash/maphash"
+
+// segment identifies which of a tinyLFUPolicy's lists holds a key.
+type segment int
+
+const (
+	windowSegment segment = iota
+	probationSegment
+	protectedSegment
+)
+
+// tinyLFUPolicy implements W-TinyLFU, per Einziger et al. New keys enter a small
+// LRU window. Keys evicted from the window are candidates for the main space,
+// a segmented LRU of probation and protected lists, and are only admitted if the
+// sketch estimates they are accessed more often than the main space's victim.
+type tinyLFUPolicy[K comparable] struct {
+	window, probation, protected *doublyLinkedList[K, segment]
+	// Nodes hold their segment as their item.
+	nodes        map[K]*node[K, segment]
+	windowCap    int
+	mainCap      int
+	protectedCap int
+	sketch       *countMinSketch[K]
+}
+
+func newTinyLFUPolicy[K comparable](capacity int) *tinyLFUPolicy[K] {
+	p := &tinyLFUPolicy[K]{
+		window:    newDoublyLinkedList[K, segment](),
+		probation: newDoublyLinkedList[K, segment](),
+		protected: newDoublyLinkedList[K, segment](),
+		nodes:     make(map[K]*node[K, segment]),
+	}
+	p.Resize(capacity)
+
+	return p
+}
+
+// Resize recomputes the segments' shares of the capacity, demoting protected
+// keys over their share, and replaces the sketch if its width no longer suits
+// the capacity, which forgets the keys' frequencies.
+func (p *tinyLFUPolicy[K]) Resize(capacity int) {
+	// The window is 1% of capacity, and the protected segment 80% of the main space.
+	p.windowCap = max(1, capacity/100)
+	p.mainCap = capacity - p.windowCap
+	p.protectedCap = p.mainCap * 4 / 5
+	for p.protected.count > p.protectedCap {
+		p.moveTo(p.protected.tail, probationSegment)
+	}
+
+	if width, _ := sketchWidth(capacity); p.sketch == nil || len(p.sketch.rows[0]) != width {
+		p.sketch = newCountMinSketch[K](capacity)
+	}
+}
+
+func (p *tinyLFUPolicy[K]) compact() {
+	p.nodes = compactMap(p.nodes)
+}
+
+func (p *tinyLFUPolicy[K]) list(id segment) *doublyLinkedList[K, segment] {
+	switch id {
+	case windowSegment:
+		return p.window
+	case probationSegment:
+		return p.probation
+	}
+	return p.protected
+}
+
+// moveTo moves n to the front of the identified segment.
+func (p *tinyLFUPolicy[K]) moveTo(n *node[K, segment], id segment) {
+	_ = p.list(n.item).Remove(n)
+	n.item = id
+	p.list(id).Prepend(n)
+}
+
+func (p *tinyLFUPolicy[K]) Add(key K) {
+	p.sketch.Increment(key)
+
+	n := &node[K, segment]{key: key, item: windowSegment}
+	p.nodes[key] = n
+	p.window.Prepend(n)
+}
+
+func (p *tinyLFUPolicy[K]) Access(key K) {
+	p.sketch.Increment(key)
+
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	switch n.item {
+	case windowSegment, protectedSegment:
+		_ = p.list(n.item).RotateFront(n)
+	case probationSegment:
+		p.moveTo(n, protectedSegment)
+		// Demote the protected segment's least-recently-used key if it is over its share.
+		if p.protected.count > p.protectedCap {
+			p.moveTo(p.protected.tail, probationSegment)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok {
+		_ = p.list(n.item).Remove(n)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *tinyLFUPolicy[K]) Evict() (key K, ok bool) {
+	for p.window.count > p.windowCap {
+		candidate := p.window.tail
+		if p.probation.count+p.protected.count < p.mainCap {
+			p.moveTo(candidate, probationSegment)
+			continue
+		}
+
+		victim := p.probation.tail
+		if victim == nil {
+			victim = p.protected.tail
+		}
+		// The candidate is only admitted if it is estimated to be used more often.
+		if victim != nil && p.sketch.Estimate(candidate.key) > p.sketch.Estimate(victim.key) {
+			p.moveTo(candidate, probationSegment)
+			return p.forget(victim), true
+		}
+		return p.forget(candidate), true
+	}
+
+	// The window is within its share, so the main space is over its share.
+	for _, victim := range []*node[K, segment]{p.probation.tail, p.protected.tail, p.window.tail} {
+		if victim != nil {
+			return p.forget(victim), true
+		}
+	}
+	return
+}
+
+// forget removes n entirely and returns its key.
+func (p *tinyLFUPolicy[K]) forget(n *node[K, segment]) K {
+	_ = p.list(n.item).Remove(n)
+	delete(p.nodes, n.key)
+	return n.key
+}
+
+const (
+	sketchDepth = 4
+	// Counters saturate at sketchMax, i.e. they are effectively four bits.
+	sketchMax = 15
+)
+
+// Each row's index is derived from the key's hash by multiply-shift hashing
+// with a distinct odd multiplier, so that keys colliding in one row are unlikely
+// to collide in the others.
+var sketchMultipliers = [sketchDepth]uint64{
+	0x9e3779b97f4a7c15,
+	0xc2b2ae3d27d4eb4f,
+	0x165667b19e3779f9,
+	0xd6e8feb86659fd93,
+}
+
+// countMinSketch estimates the access frequency of keys in constant space.
+// Each key increments one counter per row, and its estimate is the minimum
+// of them, which only overestimates due to collisions. Counters are halved
+// periodically so that estimates favor recent frequency.
+type countMinSketch[K comparable] struct {
+	rows [sketchDepth][]uint8
+	// shift keeps the top lg(width) bits of a product as an index.
+	shift     uint
+	seed      maphash.Seed
+	additions int
+	resetAt   int
+}
+
+// sketchWidth returns the number of counters per row of a sketch for a cache of
+// the passed capacity, and its log2. The width is a power of two, and several
+// counters per cached item so that the many uncached keys seen rarely collide with them.
+func sketchWidth(capacity int) (width int, bits uint) {
+	width, bits = 16, 4
+	for width < 4*capacity {
+		width <<= 1
+		bits++
+	}
+	return
+}
+
+func newCountMinSketch[K comparable](capacity int) *countMinSketch[K] {
+	width, bits := sketchWidth(capacity)
+	sketch := &countMinSketch[K]{
+		shift: 64 - bits,
+		seed:  maphash.MakeSeed(),
+		// The sample size, per the W-TinyLFU paper.
+		resetAt: 10 * max(capacity, 1),
+	}
+	for i := range sketch.rows {
+		sketch.rows[i] = make([]uint8, width)
+	}
+
+	return sketch
+}
+
+// indexes returns the counter index of key in each row.
+func (sketch *countMinSketch[K]) indexes(key K) (indexes [sketchDepth]uint64) {
+	h := maphash.Comparable(sketch.seed, key)
+	for i, m := range sketchMultipliers {
+		indexes[i] = (h * m) >> sketch.shift
+	}
+	return
+}
+
+// Increment counts an access of key.
+func (sketch *countMinSketch[K]) Increment(key K) {
+	for row, i := range sketch.indexes(key) {
+		if sketch.rows[row][i] < sketchMax {
+			sketch.rows[row][i]++
+		}
+	}
+
+	sketch.additions++
+	if sketch.additions >= sketch.resetAt {
+		sketch.age()
+	}
+}
+
+// Estimate returns the approximate access count of key.
+func (sketch *countMinSketch[K]) Estimate(key K) (estimate uint8) {
+	estimate = sketchMax
+	for row, i := range sketch.indexes(key) {
+		estimate = min(estimate, sketch.rows[row][i])
+	}
+	return
+}
+
+// age halves every counter.
+func (sketch *countMinSketch[K]) age() {
+	for _, row := range sketch.rows {
+		for i := range row {
+			row[i] >>= 1
+		}
+	}
+	sketch.additions /= 2
+}
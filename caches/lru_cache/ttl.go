@@ -0,0 +1,108 @@
+package lru_cache
+
+import "time"
+
+// Clock abstracts time so that expiration can be tested without sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTicker returns a Ticker that fires every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker mirrors time.Ticker, as returned by Clock.NewTicker.
+type Ticker interface {
+	// C returns the channel on which ticks are delivered.
+	C() <-chan time.Time
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// realClock implements Clock using the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// expired returns true if target has an expiration time that has passed.
+func (cache *Cache[K, V]) expired(target *node[K, V]) bool {
+	return !target.expires.IsZero() && !cache.clock.Now().Before(target.expires)
+}
+
+// setExpiry sets target to expire after ttl, if it is positive, and to become
+// stale after the cache's soft TTL, if one was set.
+func (cache *Cache[K, V]) setExpiry(target *node[K, V], ttl time.Duration) {
+	target.expires, target.stale = time.Time{}, time.Time{}
+	if ttl <= 0 && cache.softTTL <= 0 {
+		return
+	}
+
+	now := cache.clock.Now()
+	if ttl > 0 {
+		target.expires = now.Add(ttl)
+	}
+	if cache.softTTL > 0 {
+		target.stale = now.Add(cache.softTTL)
+	}
+}
+
+// DeleteExpired removes all expired items, and any expired GetOrLoad errors,
+// and returns the number of items removed.
+func (cache *Cache[K, V]) DeleteExpired() (removed int) {
+	cache.loadMu.Lock()
+	now := cache.clock.Now()
+	for key, negative := range cache.negatives {
+		if !now.Before(negative.expires) {
+			delete(cache.negatives, key)
+		}
+	}
+	cache.loadMu.Unlock()
+
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	for current := cache.itemList.head; current != nil; {
+		// Save next before current is unlinked.
+		next := current.next
+		if cache.expired(current) {
+			cache.removeNode(current, EvictedExpired)
+			removed++
+		}
+		current = next
+	}
+
+	return
+}
+
+func (cache *Cache[K, V]) startJanitor(interval time.Duration) {
+	cache.stop = make(chan struct{})
+	cache.done = make(chan struct{})
+	ticker := cache.clock.NewTicker(interval)
+
+	go func() {
+		defer close(cache.done)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cache.stop:
+				return
+			case <-ticker.C():
+				cache.DeleteExpired()
+			}
+		}
+	}()
+}
@@ -0,0 +1,171 @@
+package lru_cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeClock is a manually advanced Clock. Its tickers are unbuffered, such that
+// a tick sent by Advance is only delivered once the receiver is ready for it.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{c: make(chan time.Time)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, without firing tickers.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Tick blocks until every ticker has received the current time.
+func (c *fakeClock) Tick() {
+	c.mu.Lock()
+	now, tickers := c.now, c.tickers
+	c.mu.Unlock()
+
+	for _, t := range tickers {
+		t.c <- now
+	}
+}
+
+type fakeTicker struct {
+	c chan time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {}
+
+func TestExpiration(t *testing.T) {
+	Convey("Expiration tests", t, func() {
+		clock := newFakeClock()
+
+		Convey("Given an item put with a ttl, Get fails once the ttl has passed", func() {
+			cache, err := New[string, int](10, WithClock(clock))
+			So(err, ShouldBeNil)
+
+			err = cache.PutWithTTL("abc", 1, time.Minute)
+			So(err, ShouldBeNil)
+			err = cache.Put("forever", 2)
+			So(err, ShouldBeNil)
+
+			clock.Advance(59 * time.Second)
+			val, ok := cache.Get("abc")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 1)
+
+			clock.Advance(time.Second)
+			_, ok = cache.Get("abc")
+			So(ok, ShouldBeFalse)
+			// The expired item was removed lazily.
+			So(cache.itemList.count, ShouldEqual, 1)
+
+			_, ok = cache.Get("forever")
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Given a default ttl, Put items expire", func() {
+			cache, err := New[string, int](10, WithClock(clock), WithDefaultTTL(time.Second))
+			So(err, ShouldBeNil)
+
+			So(cache.Put("abc", 1), ShouldBeNil)
+			// A per-item ttl overrides the default.
+			So(cache.PutWithTTL("def", 2, time.Hour), ShouldBeNil)
+
+			clock.Advance(time.Second)
+			_, ok := cache.Get("abc")
+			So(ok, ShouldBeFalse)
+			_, ok = cache.Get("def")
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Given an expired item, Put replaces it rather than failing", func() {
+			cache, err := New[string, int](10, WithClock(clock))
+			So(err, ShouldBeNil)
+
+			So(cache.PutWithTTL("abc", 1, time.Second), ShouldBeNil)
+			So(cache.Put("abc", 2), ShouldEqual, ErrDuplicateItem)
+
+			clock.Advance(time.Second)
+			So(cache.Put("abc", 2), ShouldBeNil)
+			val, ok := cache.Get("abc")
+			So(ok, ShouldBeTrue)
+			So(val, ShouldEqual, 2)
+			So(cache.itemList.count, ShouldEqual, 1)
+		})
+
+		Convey("DeleteExpired removes only expired items", func() {
+			cache, err := New[int, int](10, WithClock(clock))
+			So(err, ShouldBeNil)
+
+			for i := 0; i < 6; i++ {
+				So(cache.PutWithTTL(i, i, time.Duration(i%2+1)*time.Minute), ShouldBeNil)
+			}
+
+			clock.Advance(time.Minute)
+			So(cache.DeleteExpired(), ShouldEqual, 3)
+			So(cache.itemList.count, ShouldEqual, 3)
+			for i := 0; i < 6; i++ {
+				_, ok := cache.Get(i)
+				So(ok, ShouldEqual, i%2 == 1)
+			}
+		})
+
+		Convey("Given a janitor, expired items are swept on each tick until Close", func() {
+			cache, err := New[int, int](10, WithClock(clock), WithJanitor(time.Minute))
+			So(err, ShouldBeNil)
+
+			So(cache.PutWithTTL(1, 1, time.Minute), ShouldBeNil)
+			So(cache.PutWithTTL(2, 2, time.Hour), ShouldBeNil)
+
+			clock.Advance(time.Minute)
+			// The second tick is only received once the first sweep has completed.
+			clock.Tick()
+			clock.Tick()
+
+			cache.mu.RLock()
+			So(cache.itemList.count, ShouldEqual, 1)
+			_, ok := cache.itemMap[1]
+			So(ok, ShouldBeFalse)
+			cache.mu.RUnlock()
+
+			So(cache.Close(), ShouldBeNil)
+			So(cache.Close(), ShouldBeNil)
+		})
+
+		Convey("Invalid options are rejected", func() {
+			_, err := New[int, int](10, WithDefaultTTL(-time.Second))
+			So(err, ShouldBeError, ErrInvalidOption)
+			_, err = New[int, int](10, WithClock(nil))
+			So(err, ShouldBeError, ErrInvalidOption)
+		})
+	})
+}
@@ -0,0 +1,115 @@
+package lru_cache
+
+// queueID identifies which of a twoQueuePolicy's lists holds a key.
+type queueID int
+
+const (
+	recentQueue queueID = iota
+	frequentQueue
+	ghostQueue
+)
+
+// twoQueuePolicy implements TwoQueue. Recent is the probationary FIFO,
+// frequent is the LRU of keys accessed more than once, and ghosts is a
+// FIFO of keys evicted from recent, which hold no cached items.
+type twoQueuePolicy[K comparable] struct {
+	recent, frequent, ghosts *doublyLinkedList[K, queueID]
+	// Nodes hold their queue as their item.
+	nodes map[K]*node[K, queueID]
+	// Recent is favored for eviction over its share of the capacity.
+	recentCap int
+	ghostCap  int
+}
+
+func newTwoQueuePolicy[K comparable](capacity int) *twoQueuePolicy[K] {
+	return &twoQueuePolicy[K]{
+		recent:    newDoublyLinkedList[K, queueID](),
+		frequent:  newDoublyLinkedList[K, queueID](),
+		ghosts:    newDoublyLinkedList[K, queueID](),
+		nodes:     make(map[K]*node[K, queueID]),
+		recentCap: max(1, capacity/4),
+		ghostCap:  max(1, capacity/2),
+	}
+}
+
+func (p *twoQueuePolicy[K]) queue(id queueID) *doublyLinkedList[K, queueID] {
+	switch id {
+	case recentQueue:
+		return p.recent
+	case frequentQueue:
+		return p.frequent
+	}
+	return p.ghosts
+}
+
+// moveTo moves n to the front of the identified queue.
+func (p *twoQueuePolicy[K]) moveTo(n *node[K, queueID], id queueID) {
+	_ = p.queue(n.item).Remove(n)
+	n.item = id
+	p.queue(id).Prepend(n)
+}
+
+func (p *twoQueuePolicy[K]) Add(key K) {
+	// Recently evicted keys have proven to be re-used, so they skip probation.
+	if n, ok := p.nodes[key]; ok {
+		p.moveTo(n, frequentQueue)
+		return
+	}
+
+	n := &node[K, queueID]{key: key, item: recentQueue}
+	p.nodes[key] = n
+	p.recent.Prepend(n)
+}
+
+func (p *twoQueuePolicy[K]) Access(key K) {
+	if n, ok := p.nodes[key]; ok && n.item != ghostQueue {
+		p.moveTo(n, frequentQueue)
+	}
+}
+
+func (p *twoQueuePolicy[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok && n.item != ghostQueue {
+		_ = p.queue(n.item).Remove(n)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *twoQueuePolicy[K]) Resize(capacity int) {
+	p.recentCap = max(1, capacity/4)
+	p.ghostCap = max(1, capacity/2)
+	for p.ghosts.count > p.ghostCap {
+		oldest := p.ghosts.tail
+		_ = p.ghosts.Remove(oldest)
+		delete(p.nodes, oldest.key)
+	}
+}
+
+func (p *twoQueuePolicy[K]) compact() {
+	p.nodes = compactMap(p.nodes)
+}
+
+func (p *twoQueuePolicy[K]) Evict() (key K, ok bool) {
+	victim := p.frequent.tail
+	if p.recent.count > p.recentCap || victim == nil {
+		victim = p.recent.tail
+	}
+	if victim == nil {
+		return
+	}
+
+	if victim.item == frequentQueue {
+		_ = p.frequent.Remove(victim)
+		delete(p.nodes, victim.key)
+		return victim.key, true
+	}
+
+	// Remember keys evicted from probation, forgetting the oldest.
+	p.moveTo(victim, ghostQueue)
+	if p.ghosts.count > p.ghostCap {
+		oldest := p.ghosts.tail
+		_ = p.ghosts.Remove(oldest)
+		delete(p.nodes, oldest.key)
+	}
+
+	return victim.key, true
+}
@@ -1,6 +1,7 @@
 package avl
 
 import (
+	"cmp"
 	"container/list"
 	"errors"
 	"fmt"
@@ -8,14 +9,18 @@ import (
 	"strings"
 )
 
-type Node struct {
-	left, right *Node
-	data        int
+type Node[K, V any] struct {
+	left, right *Node[K, V]
+	key         K
+	value       V
 	// Height is defined as the longest path from this node to a leaf (thus zero if it is a leaf).
 	height int
+	// Size is the number of nodes in the subtree rooted at this node, including itself.
+	size int
 }
 
-// AvlTrees implement a balance property ensuring that sibling subtrees
+// AvlTrees are ordered maps, whose keys are ordered by a comparison func, and
+// which implement a balance property ensuring that sibling subtrees
 // do not differ in height by more than one (a modifiable parameter),
 // such that operations are O(lg(n)) on average. The balance property
 // is implemented using basic rotation operations. Treaps and skiplists
@@ -25,14 +30,18 @@ type Node struct {
 // Treaps and skiplists both require external rand sys dependencies.
 // NOTE: this is an exercise, this tree has not been fully evaluated for
 // correctness, performance, nor concurrent usage.
-type AvlTree struct {
-	root      *Node
+type AvlTree[K, V any] struct {
+	root      *Node[K, V]
 	nodeCount int
+	// cmp returns a negative number if a < b, a positive number if a > b, and zero if they are equal.
+	cmp func(a, b K) int
 }
 
 var (
 	ErrDuplicateItem error = errors.New("duplicate item")
 	ErrItemNotFound  error = errors.New("item not found")
+	// ErrNotSorted is returned when keys are not in strictly ascending order.
+	ErrNotSorted error = errors.New("keys not sorted")
 )
 
 // DFSOrder merely describes a tree traversal order, per classic tree ops.
@@ -47,36 +56,62 @@ const (
 // The allowed difference between right/left subtrees.
 const allowedImbalance = 1
 
-// NewTree returns an empty AVL tree.
-func NewTree() *AvlTree {
-	return &AvlTree{}
+// NewTree returns an empty AVL tree of naturally ordered keys.
+func NewTree[K cmp.Ordered, V any]() *AvlTree[K, V] {
+	return NewTreeFunc[K, V](cmp.Compare[K])
 }
 
-// Insert a new item in the tree.
-func (t *AvlTree) Insert(n int) error {
-	return t.insert(&t.root, n)
+// NewTreeFunc returns an empty AVL tree whose keys are ordered by cmp, which
+// returns a negative number if a < b, a positive number if a > b, and zero if
+// they are equal.
+func NewTreeFunc[K, V any](cmp func(a, b K) int) *AvlTree[K, V] {
+	return &AvlTree[K, V]{
+		cmp: cmp,
+	}
+}
+
+// Len returns the number of items in the tree.
+func (t *AvlTree[K, V]) Len() int {
+	return t.nodeCount
+}
+
+// Insert a new item in the tree, or return ErrDuplicateItem if its key exists.
+func (t *AvlTree[K, V]) Insert(key K, value V) error {
+	return t.insert(&t.root, key, value, false)
 }
 
-func (t *AvlTree) insert(node **Node, n int) (err error) {
+// Put inserts a new item in the tree, or replaces the value of an existing key.
+func (t *AvlTree[K, V]) Put(key K, value V) {
+	_ = t.insert(&t.root, key, value, true)
+}
+
+func (t *AvlTree[K, V]) insert(node **Node[K, V], key K, value V, replace bool) (err error) {
 	// base case
 	if *node == nil {
-		*node = &Node{
-			data:   n,
+		*node = &Node[K, V]{
+			key:    key,
+			value:  value,
 			height: 0,
+			size:   1,
 		}
 		t.nodeCount++
 		return
 	}
 
-	if n == (*node).data {
-		err = ErrDuplicateItem
+	c := t.cmp(key, (*node).key)
+	if c == 0 {
+		if !replace {
+			return ErrDuplicateItem
+		}
+		// The tree's shape is unchanged, so there is nothing to rebalance.
+		(*node).value = value
 		return
 	}
 
-	if n < (*node).data {
-		err = t.insert(&(*node).left, n)
+	if c < 0 {
+		err = t.insert(&(*node).left, key, value, replace)
 	} else {
-		err = t.insert(&(*node).right, n)
+		err = t.insert(&(*node).right, key, value, replace)
 	}
 
 	if err != nil {
@@ -84,13 +119,14 @@ func (t *AvlTree) insert(node **Node, n int) (err error) {
 	}
 
 	setHeight(*node)
+	setSize(*node)
 
 	t.balance(node)
 
 	return nil
 }
 
-func (t *AvlTree) balance(node **Node) {
+func (t *AvlTree[K, V]) balance(node **Node[K, V]) {
 	leftHeight := height((*node).left)
 	rightHeight := height((*node).right)
 
@@ -114,65 +150,83 @@ func (t *AvlTree) balance(node **Node) {
 	}
 }
 
-func outerLeftDeeper(node *Node) bool {
-	return height(node.left.left) > height(node.left.right)
+// The outer grandchild is also taken to be deeper if it is as deep as the inner
+// one, which only occurs after deletions, since a single rotation then restores
+// the balance whereas a double rotation may not.
+func outerLeftDeeper[K, V any](node *Node[K, V]) bool {
+	return height(node.left.left) >= height(node.left.right)
 }
 
-func outerRightDeeper(node *Node) bool {
-	return height(node.right.right) > height(node.right.left)
+func outerRightDeeper[K, V any](node *Node[K, V]) bool {
+	return height(node.right.right) >= height(node.right.left)
 }
 
 // The rotation funcs are best understood via diagram.
-func rotateWithLeftChild(root **Node) {
+func rotateWithLeftChild[K, V any](root **Node[K, V]) {
 	k2 := *root
 	k1 := k2.left
 	k2.left = k1.right
 	k1.right = k2
 	*root = k1
 
-	// Note: this order of height updates is required.
+	// Note: this order of height and size updates is required.
 	setHeight(k2)
 	setHeight(k1)
+	setSize(k2)
+	setSize(k1)
 }
 
 // The rotation funcs are best understood via diagram.
-func rotateWithRightChild(root **Node) {
+func rotateWithRightChild[K, V any](root **Node[K, V]) {
 	k2 := *root
 	k1 := k2.right
 	k2.right = k1.left
 	k1.left = k2
 	*root = k1
 
-	// Note: this order of height updates is required.
+	// Note: this order of height and size updates is required.
 	setHeight(k2)
 	setHeight(k1)
+	setSize(k2)
+	setSize(k1)
 }
 
-func setHeight(node *Node) {
+func setHeight[K, V any](node *Node[K, V]) {
 	node.height = 1 + max(height(node.left), height(node.right))
 }
 
+func setSize[K, V any](node *Node[K, V]) {
+	node.size = 1 + size(node.left) + size(node.right)
+}
+
 // The double rotation operations can be performed via two single
 // rotations, though a pencil example is necessary to demonstrate.
-func doubleRotateWithLeftChild(node **Node) {
+func doubleRotateWithLeftChild[K, V any](node **Node[K, V]) {
 	rotateWithRightChild(&(*node).left)
 	rotateWithLeftChild(node)
 }
 
 // The double rotation operations can be performed via two single
 // rotations, though a pencil example is necessary to demonstrate.
-func doubleRotateWithRightChild(node **Node) {
+func doubleRotateWithRightChild[K, V any](node **Node[K, V]) {
 	rotateWithLeftChild(&(*node).right)
 	rotateWithRightChild(node)
 }
 
-func height(node *Node) int {
+func height[K, V any](node *Node[K, V]) int {
 	if node == nil {
 		return -1
 	}
 	return node.height
 }
 
+func size[K, V any](node *Node[K, V]) int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
 func max(x, y int) int {
 	if x > y {
 		return x
@@ -180,18 +234,21 @@ func max(x, y int) int {
 	return y
 }
 
-// Delete removes an item from the tree, if it exists.
-func (t *AvlTree) Delete(n int) error {
-	err := t.delete(&t.root, n)
+// Delete removes an item from the tree, or returns ErrItemNotFound.
+func (t *AvlTree[K, V]) Delete(key K) error {
+	err := t.delete(&t.root, key)
 	if err == nil {
 		t.nodeCount--
 	}
 	return err
 }
 
-func (t *AvlTree) delete(node **Node, n int) (err error) {
+func (t *AvlTree[K, V]) delete(node **Node[K, V], key K) (err error) {
 	defer func() {
 		if err == nil && *node != nil {
+			// The path to the deleted node is updated bottom-up as the recursion unwinds.
+			setHeight(*node)
+			setSize(*node)
 			t.balance(node)
 		}
 	}()
@@ -202,12 +259,13 @@ func (t *AvlTree) delete(node **Node, n int) (err error) {
 		return
 	}
 
-	if n < (*node).data {
-		err = t.delete(&(*node).left, n)
+	c := t.cmp(key, (*node).key)
+	if c < 0 {
+		err = t.delete(&(*node).left, key)
 		return
 	}
-	if n > (*node).data {
-		err = t.delete(&(*node).right, n)
+	if c > 0 {
+		err = t.delete(&(*node).right, key)
 		return
 	}
 
@@ -218,9 +276,10 @@ func (t *AvlTree) delete(node **Node, n int) (err error) {
 		// TODO: this introduces a bias whereby a succession of deletions
 		// selects the right-inner child as replacement, thus making the right tree
 		// shallower over time. I have not considered the full effects.
-		(*node).data = findMin((*node).right).data
+		successor := findMin((*node).right)
+		(*node).key, (*node).value = successor.key, successor.value
 		// err intentionally discarded because we know the item exists from the previous line
-		_ = t.delete(&(*node).right, (*node).data)
+		_ = t.delete(&(*node).right, (*node).key)
 		return
 	}
 
@@ -247,12 +306,12 @@ func (t *AvlTree) delete(node **Node, n int) (err error) {
 	return
 }
 
-type nodeVisitor func(*Node)
+type nodeVisitor[K, V any] func(*Node[K, V])
 
-func (t *AvlTree) FormatDFS(order DFSOrder) string {
+func (t *AvlTree[K, V]) FormatDFS(order DFSOrder) string {
 	sb := strings.Builder{}
-	visitor := nodeVisitor(func(node *Node) {
-		sb.WriteString(fmt.Sprintf("%d ", node.data))
+	visitor := nodeVisitor[K, V](func(node *Node[K, V]) {
+		sb.WriteString(fmt.Sprintf("%v ", node.key))
 	})
 
 	switch order {
@@ -269,7 +328,7 @@ func (t *AvlTree) FormatDFS(order DFSOrder) string {
 	return sb.String()
 }
 
-func preorder(node *Node, visitor nodeVisitor) {
+func preorder[K, V any](node *Node[K, V], visitor nodeVisitor[K, V]) {
 	if node == nil {
 		return
 	}
@@ -278,7 +337,7 @@ func preorder(node *Node, visitor nodeVisitor) {
 	preorder(node.right, visitor)
 }
 
-func inorder(node *Node, visitor nodeVisitor) {
+func inorder[K, V any](node *Node[K, V], visitor nodeVisitor[K, V]) {
 	if node == nil {
 		return
 	}
@@ -287,7 +346,7 @@ func inorder(node *Node, visitor nodeVisitor) {
 	inorder(node.right, visitor)
 }
 
-func postorder(node *Node, visitor nodeVisitor) {
+func postorder[K, V any](node *Node[K, V], visitor nodeVisitor[K, V]) {
 	if node == nil {
 		return
 	}
@@ -300,7 +359,7 @@ func postorder(node *Node, visitor nodeVisitor) {
 // spacing algorithm to equally distribute the nodes at a given level. This isn't
 // the tightest format to visualize parent-child relationships, but is useful
 // for manual testing.
-func (t *AvlTree) FormatBFS() string {
+func (t *AvlTree[K, V]) FormatBFS() string {
 	if t.root == nil {
 		return "<empty>"
 	}
@@ -324,7 +383,7 @@ func (t *AvlTree) FormatBFS() string {
 	var sb, line strings.Builder
 	var curLevel uint
 
-	visitor := func(node *Node, nodeNumber uint) {
+	visitor := func(node *Node[K, V], nodeNumber uint) {
 		// Stateful values: the formatting state is fully defined by the height/level in the tree.
 		// When a new level is encounted, all the spacing parameters are updated.
 		level := leadingBitIndex(nodeNumber)
@@ -352,8 +411,8 @@ func (t *AvlTree) FormatBFS() string {
 		for line.Len() < (as - 1) {
 			line.WriteString(spaceChar)
 		}
-		//ns := fmt.Sprintf("%1.0e", float64(node.data))
-		ns := fmt.Sprintf("%3d", node.data)
+		//ns := fmt.Sprintf("%1.0e", float64(node.key))
+		ns := fmt.Sprintf("%3v", node.key)
 		ns = strings.Replace(ns, " ", spaceChar, -1)
 		line.WriteString(ns)
 	}
@@ -383,7 +442,7 @@ func leadingBitIndex(n uint) (i uint) {
 	return
 }
 
-func (t *AvlTree) visitBFS(fn func(*Node, uint)) {
+func (t *AvlTree[K, V]) visitBFS(fn func(*Node[K, V], uint)) {
 	if t.root == nil {
 		return
 	}
@@ -394,7 +453,7 @@ func (t *AvlTree) visitBFS(fn func(*Node, uint)) {
 		// relations can be known, since a node's left child is 2*number and right child
 		// is 2*number+1, its height is floor(lg(number)), etc.
 		number uint
-		node   *Node
+		node   *Node[K, V]
 	}
 
 	q := list.New()
@@ -425,26 +484,38 @@ func (t *AvlTree) visitBFS(fn func(*Node, uint)) {
 	}
 }
 
-func findMin(node *Node) *Node {
+func findMin[K, V any](node *Node[K, V]) *Node[K, V] {
 	if node.left == nil {
 		return node
 	}
 	return findMin(node.left)
 }
 
-// Find returns a node given its value; obviously this is
-// redundant, it is purely for demonstration.
-// Returns nil if not found.
-func (t *AvlTree) Find(n int) *Node {
-	return t.find(t.root, n)
+// Get returns the value stored under key, and whether it exists.
+func (t *AvlTree[K, V]) Get(key K) (value V, ok bool) {
+	node := t.find(t.root, key)
+	if node == nil {
+		return value, false
+	}
+	return node.value, true
+}
+
+// Find returns the value stored under key, and whether it exists, per Get.
+func (t *AvlTree[K, V]) Find(key K) (value V, ok bool) {
+	return t.Get(key)
 }
 
-func (t *AvlTree) find(node *Node, n int) *Node {
-	if node == nil || node.data == n {
+// find returns the node of key, or nil if not found.
+func (t *AvlTree[K, V]) find(node *Node[K, V], key K) *Node[K, V] {
+	if node == nil {
+		return nil
+	}
+	c := t.cmp(key, node.key)
+	if c == 0 {
 		return node
 	}
-	if n < node.data {
-		return t.find(node.left, n)
+	if c < 0 {
+		return t.find(node.left, key)
 	}
-	return t.find(node.right, n)
+	return t.find(node.right, key)
 }
@@ -19,12 +19,12 @@ func FuzzInsertion(f *testing.F) {
 		usedInts[tc] = true
 	}
 
-	tr := NewTree()
+	tr := NewTree[int, int]()
 	f.Fuzz(func(t *testing.T, in int) {
 		n := in % modulus
 		if _, ok := usedInts[n]; !ok {
 			usedInts[n] = true
-			err := tr.Insert(in)
+			err := tr.Insert(in, in)
 			t.Logf("Input %d", in)
 			if err != nil {
 				t.Errorf("Inserted %d but got err %v", in, err)
@@ -3,6 +3,7 @@ package avl
 import (
 	"math"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,12 +12,24 @@ import (
 
 // TODO: memory leak benchmarking, perf benchmarking
 
+// checkBalanced returns true if every node's height and size are those of its
+// subtree, and its subtrees' heights differ by at most allowedImbalance.
+func checkBalanced[K, V any](node *Node[K, V]) bool {
+	if node == nil {
+		return true
+	}
+	lh, rh := height(node.left), height(node.right)
+	return node.height == 1+max(lh, rh) && node.size == 1+size(node.left)+size(node.right) &&
+		lh-rh <= allowedImbalance && rh-lh <= allowedImbalance &&
+		checkBalanced(node.left) && checkBalanced(node.right)
+}
+
 func TestFormatting(t *testing.T) {
 	Convey("Test recursive formatters", t, func() {
-		t := NewTree()
+		t := NewTree[int, int]()
 		vals := []int{1, 2, 3, 4, 5, 6, 7, 8}
 		for _, v := range vals {
-			err := t.Insert(v)
+			err := t.Insert(v, v)
 			So(err, ShouldBeNil)
 		}
 		/*
@@ -62,12 +75,12 @@ func TestFormatting(t *testing.T) {
 ......................................8
 `)
 
-			emptyTree := NewTree()
+			emptyTree := NewTree[int, int]()
 			s = emptyTree.FormatBFS()
 			So(s, ShouldEqual, "<empty>")
 
 			visits := 0
-			emptyTree.visitBFS(func(node *Node, nodeNum uint) {
+			emptyTree.visitBFS(func(node *Node[int, int], nodeNum uint) {
 				visits++
 			})
 			So(visits, ShouldEqual, 0)
@@ -78,48 +91,49 @@ func TestFormatting(t *testing.T) {
 func TestFind(t *testing.T) {
 	Convey("Find tests", t, func() {
 		Convey("When Find is called for an item that exists", func() {
-			t := NewTree()
-			err := t.Insert(7)
+			t := NewTree[int, string]()
+			err := t.Insert(7, "seven")
 			So(err, ShouldBeNil)
-			node := t.Find(7)
-			So(node, ShouldNotBeNil)
-			So(node.data, ShouldEqual, 7)
+			value, ok := t.Find(7)
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, "seven")
 		})
 
 		Convey("When Find is called for an item that does not exist", func() {
-			t := NewTree()
-			err := t.Insert(7)
+			t := NewTree[int, string]()
+			err := t.Insert(7, "seven")
 			So(err, ShouldBeNil)
-			node := t.Find(5)
-			So(node, ShouldBeNil)
+			value, ok := t.Find(5)
+			So(ok, ShouldBeFalse)
+			So(value, ShouldEqual, "")
 		})
 
 		Convey("When Find is called on an empty tree", func() {
-			t := NewTree()
-			node := t.Find(5)
-			So(node, ShouldBeNil)
+			t := NewTree[int, string]()
+			_, ok := t.Find(5)
+			So(ok, ShouldBeFalse)
 		})
 
 		Convey("When Find is called on a complex tree", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 			vals := []int{4, 2, 6, 1, 3, 5, 7}
 			for _, v := range vals {
-				err := t.Insert(v)
+				err := t.Insert(v, v*10)
 				So(err, ShouldBeNil)
 			}
 
 			for _, v := range vals {
-				node := t.Find(v)
-				So(node, ShouldNotBeNil)
-				So(node.data, ShouldEqual, v)
+				value, ok := t.Find(v)
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, v*10)
 			}
 
 			// For good measure, search for something that doesn't exist
 			// and would be deep in the tree.
-			node := t.Find(367)
-			So(node, ShouldBeNil)
-			node = t.Find(-1)
-			So(node, ShouldBeNil)
+			_, ok := t.Find(367)
+			So(ok, ShouldBeFalse)
+			_, ok = t.Find(-1)
+			So(ok, ShouldBeFalse)
 		})
 	})
 }
@@ -128,9 +142,9 @@ func TestDelete(t *testing.T) {
 	Convey("Deletion tests", t, func() {
 		Convey("When Delete is called on a non-existent item", func() {
 			Convey("When the the item is highly nested and attempting to delete non-existent items", func() {
-				t := NewTree()
+				t := NewTree[int, int]()
 				for i := 0; i < 32; i++ {
-					err := t.Insert(i)
+					err := t.Insert(i, i)
 					So(err, ShouldBeNil)
 				}
 
@@ -141,16 +155,16 @@ func TestDelete(t *testing.T) {
 			})
 
 			Convey("When Delete is called on an empty tree", func() {
-				t := NewTree()
+				t := NewTree[int, int]()
 				err := t.Delete(123)
 				So(err, ShouldBeError, ErrItemNotFound)
 			})
 		})
 
 		Convey("When a manually defined tree has items deleted", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 			for i := 1; i <= 8; i++ {
-				err := t.Insert(i)
+				err := t.Insert(i, i)
 				So(err, ShouldBeNil)
 			}
 			So(t.nodeCount, ShouldEqual, 8)
@@ -203,9 +217,9 @@ func TestDelete(t *testing.T) {
 		})
 
 		Convey("When Delete is called on existing items", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 			for i := 0; i < 32; i++ {
-				err := t.Insert(i)
+				err := t.Insert(i, i)
 				So(err, ShouldBeNil)
 			}
 
@@ -220,13 +234,13 @@ func TestDelete(t *testing.T) {
 		})
 
 		Convey("When Delete empties a tree", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 
 			// Builds and deletes tree twice, to test edge cases such as initial
 			// state (nodeCount zero, root nil) and valid return to initial state.
 			for i := 0; i < 2; i++ {
 				for i := 0; i < 32; i++ {
-					err := t.Insert(i)
+					err := t.Insert(i, i)
 					So(err, ShouldBeNil)
 				}
 				So(t.nodeCount, ShouldEqual, 32)
@@ -240,52 +254,68 @@ func TestDelete(t *testing.T) {
 				So(t.root, ShouldBeNil)
 			}
 		})
+
+		Convey("When random items are deleted, the tree stays balanced", func() {
+			t := NewTree[int, int]()
+			r := rand.New(rand.NewSource(1))
+			balanced := true
+			for i := 0; i < 5000; i++ {
+				key := r.Intn(200)
+				if r.Intn(2) == 0 {
+					_ = t.Delete(key)
+				} else {
+					t.Put(key, i)
+				}
+				balanced = balanced && checkBalanced(t.root)
+			}
+			So(balanced, ShouldBeTrue)
+		})
 	})
 }
 
 func TestInsert(t *testing.T) {
 	Convey("Insertion tests", t, func() {
 		Convey("When calling insertion on an empty tree", func() {
-			t := NewTree()
-			err := t.Insert(7)
+			t := NewTree[int, int]()
+			err := t.Insert(7, 7)
 			So(err, ShouldBeNil)
 			So(t.root.height, ShouldEqual, 0)
 		})
 
 		Convey("When inserting duplicate items", func() {
-			t := NewTree()
-			err := t.Insert(1)
+			t := NewTree[int, int]()
+			err := t.Insert(1, 1)
 			So(err, ShouldBeNil)
-			err = t.Insert(1)
+			err = t.Insert(1, 1)
 			So(err, ShouldBeError, ErrDuplicateItem)
 
 			// Add some items for a deeper tree
 			for i := 2; i <= 8; i++ {
-				err := t.Insert(i)
+				err := t.Insert(i, i)
 				So(err, ShouldBeNil)
 			}
 
-			err = t.Insert(8)
+			err = t.Insert(8, 8)
 			So(err, ShouldBeError, ErrDuplicateItem)
 
 		})
 
 		Convey("When inserting items and left double rotation required", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 
-			err := t.Insert(4)
+			err := t.Insert(4, 4)
 			/*
 				After Insert(4):
 
 					4 (root)
 			*/
 			So(err, ShouldBeNil)
-			So(t.root.data, ShouldEqual, 4)
+			So(t.root.key, ShouldEqual, 4)
 			So(t.root.height, ShouldEqual, 0)
 			So(t.root.left, ShouldBeNil)
 			So(t.root.right, ShouldBeNil)
 
-			err = t.Insert(1)
+			err = t.Insert(1, 1)
 			/*
 				After Insert(1):
 						4 (root)
@@ -294,11 +324,11 @@ func TestInsert(t *testing.T) {
 			*/
 			So(err, ShouldBeNil)
 			So(t.root.left, ShouldNotBeNil)
-			So(t.root.left.data, ShouldEqual, 1)
+			So(t.root.left.key, ShouldEqual, 1)
 			So(t.root.left.height, ShouldEqual, 0)
 			So(t.root.height, ShouldEqual, 1)
 
-			err = t.Insert(2)
+			err = t.Insert(2, 2)
 			/*
 				After Insert(2) and double rotation:
 						2 (new root)
@@ -306,35 +336,35 @@ func TestInsert(t *testing.T) {
 					  1   4
 			*/
 			So(err, ShouldBeNil)
-			nodeTwo := t.Find(2)
+			nodeTwo := t.find(t.root, 2)
 			So(nodeTwo, ShouldNotBeNil)
 			So(nodeTwo.height, ShouldEqual, 1)
 			So(nodeTwo, ShouldEqual, t.root)
 			So(t.root.right, ShouldNotBeNil)
-			So(t.root.right.data, ShouldEqual, 4)
+			So(t.root.right.key, ShouldEqual, 4)
 			So(t.root.right.height, ShouldEqual, 0)
 			So(t.root.left.height, ShouldEqual, 0)
-			So(t.root.left.data, ShouldEqual, 1)
+			So(t.root.left.key, ShouldEqual, 1)
 
 			treeStr := t.FormatDFS(InOrder)
 			So(treeStr, ShouldEqual, "1 2 4 ")
 		})
 
 		Convey("When inserting items and right double rotation required", func() {
-			t := NewTree()
-			err := t.Insert(4)
+			t := NewTree[int, int]()
+			err := t.Insert(4, 4)
 			/*
 				After Insert(4):
 
 					4 (root)
 			*/
 			So(err, ShouldBeNil)
-			So(t.root.data, ShouldEqual, 4)
+			So(t.root.key, ShouldEqual, 4)
 			So(t.root.height, ShouldEqual, 0)
 			So(t.root.left, ShouldBeNil)
 			So(t.root.right, ShouldBeNil)
 
-			err = t.Insert(7)
+			err = t.Insert(7, 7)
 			/*
 				After Insert(7):
 
@@ -344,11 +374,11 @@ func TestInsert(t *testing.T) {
 			*/
 			So(err, ShouldBeNil)
 			So(t.root.right, ShouldNotBeNil)
-			So(t.root.right.data, ShouldEqual, 7)
+			So(t.root.right.key, ShouldEqual, 7)
 			So(t.root.right.height, ShouldEqual, 0)
 			So(t.root.height, ShouldEqual, 1)
 
-			err = t.Insert(5)
+			err = t.Insert(5, 5)
 			/*
 				After Insert(5) and double rotation:
 						5 (new root)
@@ -356,14 +386,14 @@ func TestInsert(t *testing.T) {
 					  4   7
 			*/
 			So(err, ShouldBeNil)
-			nodeFive := t.Find(5)
+			nodeFive := t.find(t.root, 5)
 			So(nodeFive, ShouldNotBeNil)
 			So(nodeFive.height, ShouldEqual, 1)
 			So(nodeFive, ShouldEqual, t.root)
 			So(t.root.right, ShouldNotBeNil)
-			So(t.root.right.data, ShouldEqual, 7)
+			So(t.root.right.key, ShouldEqual, 7)
 			So(t.root.right.height, ShouldEqual, 0)
-			So(t.root.left.data, ShouldEqual, 4)
+			So(t.root.left.key, ShouldEqual, 4)
 			So(t.root.left.height, ShouldEqual, 0)
 
 			treeStr := t.FormatDFS(InOrder)
@@ -371,21 +401,21 @@ func TestInsert(t *testing.T) {
 		})
 
 		Convey("When inserting items and left single rotation required", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 
-			err := t.Insert(4)
+			err := t.Insert(4, 4)
 			/*
 				After Insert(4):
 
 					4 (root)
 			*/
 			So(err, ShouldBeNil)
-			So(t.root.data, ShouldEqual, 4)
+			So(t.root.key, ShouldEqual, 4)
 			So(t.root.height, ShouldEqual, 0)
 			So(t.root.left, ShouldBeNil)
 			So(t.root.right, ShouldBeNil)
 
-			err = t.Insert(2)
+			err = t.Insert(2, 2)
 			/*
 				After Insert(2):
 						4 (root)
@@ -394,11 +424,11 @@ func TestInsert(t *testing.T) {
 			*/
 			So(err, ShouldBeNil)
 			So(t.root.left, ShouldNotBeNil)
-			So(t.root.left.data, ShouldEqual, 2)
+			So(t.root.left.key, ShouldEqual, 2)
 			So(t.root.left.height, ShouldEqual, 0)
 			So(t.root.height, ShouldEqual, 1)
 
-			err = t.Insert(1)
+			err = t.Insert(1, 1)
 			/*
 				After Insert(2) and single rotation:
 						2 (new root)
@@ -406,36 +436,36 @@ func TestInsert(t *testing.T) {
 					  1   4
 			*/
 			So(err, ShouldBeNil)
-			nodeTwo := t.Find(2)
+			nodeTwo := t.find(t.root, 2)
 			So(nodeTwo, ShouldNotBeNil)
 			So(nodeTwo.height, ShouldEqual, 1)
 			So(nodeTwo, ShouldEqual, t.root)
 			So(t.root.right, ShouldNotBeNil)
-			So(t.root.right.data, ShouldEqual, 4)
+			So(t.root.right.key, ShouldEqual, 4)
 			So(t.root.right.height, ShouldEqual, 0)
 			So(t.root.left.height, ShouldEqual, 0)
-			So(t.root.left.data, ShouldEqual, 1)
+			So(t.root.left.key, ShouldEqual, 1)
 
 			treeStr := t.FormatDFS(InOrder)
 			So(treeStr, ShouldEqual, "1 2 4 ")
 		})
 
 		Convey("When inserting items and right single rotation required", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 
-			err := t.Insert(1)
+			err := t.Insert(1, 1)
 			/*
 				After Insert(1):
 
 					1 (root)
 			*/
 			So(err, ShouldBeNil)
-			So(t.root.data, ShouldEqual, 1)
+			So(t.root.key, ShouldEqual, 1)
 			So(t.root.height, ShouldEqual, 0)
 			So(t.root.left, ShouldBeNil)
 			So(t.root.right, ShouldBeNil)
 
-			err = t.Insert(2)
+			err = t.Insert(2, 2)
 			/*
 				After Insert(1):
 						1 (root)
@@ -444,11 +474,11 @@ func TestInsert(t *testing.T) {
 			*/
 			So(err, ShouldBeNil)
 			So(t.root.right, ShouldNotBeNil)
-			So(t.root.right.data, ShouldEqual, 2)
+			So(t.root.right.key, ShouldEqual, 2)
 			So(t.root.right.height, ShouldEqual, 0)
 			So(t.root.height, ShouldEqual, 1)
 
-			err = t.Insert(4)
+			err = t.Insert(4, 4)
 			/*
 				After Insert(4) and single rotation:
 						2 (new root)
@@ -456,15 +486,15 @@ func TestInsert(t *testing.T) {
 					  1   4
 			*/
 			So(err, ShouldBeNil)
-			nodeTwo := t.Find(2)
+			nodeTwo := t.find(t.root, 2)
 			So(nodeTwo, ShouldNotBeNil)
 			So(nodeTwo.height, ShouldEqual, 1)
 			So(nodeTwo, ShouldEqual, t.root)
 			So(t.root.right, ShouldNotBeNil)
-			So(t.root.right.data, ShouldEqual, 4)
+			So(t.root.right.key, ShouldEqual, 4)
 			So(t.root.right.height, ShouldEqual, 0)
 			So(t.root.left.height, ShouldEqual, 0)
-			So(t.root.left.data, ShouldEqual, 1)
+			So(t.root.left.key, ShouldEqual, 1)
 
 			treeStr := t.FormatDFS(InOrder)
 			So(treeStr, ShouldEqual, "1 2 4 ")
@@ -517,9 +547,9 @@ func TestInsert(t *testing.T) {
 				},
 			}
 
-			t := NewTree()
+			t := NewTree[int, int]()
 			for _, tc := range tcs {
-				err := t.Insert(tc.val)
+				err := t.Insert(tc.val, tc.val)
 				So(err, ShouldBeNil)
 
 				// A tree's structure is fully specified by its pre-order and post-order
@@ -533,27 +563,27 @@ func TestInsert(t *testing.T) {
 		})
 
 		Convey("When building large trees sequentially (stress test)", func() {
-			t := NewTree()
+			t := NewTree[int, int]()
 			for i := 0; i < 256; i++ {
-				err := t.Insert(i)
+				err := t.Insert(i, i)
 				So(err, ShouldBeNil)
 			}
 			So(t.root.height, ShouldEqual, 8)
 
-			t = NewTree()
+			t = NewTree[int, int]()
 			for i := 255; i >= 0; i-- {
-				err := t.Insert(i)
+				err := t.Insert(i, i)
 				So(err, ShouldBeNil)
 			}
 			So(t.root.height, ShouldEqual, 8)
 
 			rand.Seed(time.Now().UnixNano())
-			t = NewTree()
+			t = NewTree[int, int]()
 			n := 256
 			for i := 0; i < n; i++ {
-				err := t.Insert(rand.Int())
+				err := t.Insert(rand.Int(), i)
 				for err != nil {
-					err = t.Insert(rand.Int())
+					err = t.Insert(rand.Int(), i)
 				}
 			}
 
@@ -565,3 +595,54 @@ func TestInsert(t *testing.T) {
 		})
 	})
 }
+
+func TestOrderedMap(t *testing.T) {
+	Convey("Ordered map tests", t, func() {
+		Convey("When Put inserts and replaces values", func() {
+			t := NewTree[string, int]()
+			t.Put("b", 2)
+			t.Put("a", 1)
+			t.Put("c", 3)
+			t.Put("b", 20)
+			So(t.Len(), ShouldEqual, 3)
+			So(t.FormatDFS(InOrder), ShouldEqual, "a b c ")
+
+			value, ok := t.Get("b")
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, 20)
+			_, ok = t.Get("d")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("When a node with two children is deleted, its successor's value moves with its key", func() {
+			t := NewTree[int, string]()
+			for i, v := range []string{"zero", "one", "two", "three", "four"} {
+				t.Put(i, v)
+			}
+
+			So(t.Delete(1), ShouldBeNil)
+			So(t.Len(), ShouldEqual, 4)
+			for i, v := range []string{"zero", "", "two", "three", "four"} {
+				value, ok := t.Get(i)
+				So(ok, ShouldEqual, v != "")
+				So(value, ShouldEqual, v)
+			}
+		})
+
+		Convey("When a custom comparator orders the keys", func() {
+			t := NewTreeFunc[string, int](func(a, b string) int {
+				return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+			})
+			t.Put("b", 1)
+			t.Put("A", 2)
+			t.Put("B", 3)
+			So(t.Len(), ShouldEqual, 2)
+			So(t.FormatDFS(InOrder), ShouldEqual, "A b ")
+
+			value, ok := t.Get("a")
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, 2)
+			So(t.Insert("a", 4), ShouldBeError, ErrDuplicateItem)
+		})
+	})
+}
@@ -1,6 +1,6 @@
 module avl
 
-go 1.19
+go 1.23
 
 require github.com/smartystreets/goconvey v1.7.2
 
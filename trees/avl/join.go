@@ -0,0 +1,237 @@
+package avl
+
+import "cmp"
+
+// Split moves the items whose keys are less than key to a new left tree, and
+// those whose keys are greater to a new right tree, and returns the value of key
+// itself, if it exists. The tree is left empty, since its nodes are reused.
+// Split is O(lg(n)), as it joins the subtrees along the path to key.
+func (t *AvlTree[K, V]) Split(key K) (left, right *AvlTree[K, V], value V, found bool) {
+	l, m, r := t.split(t.root, key)
+	t.root, t.nodeCount = nil, 0
+
+	left, right = t.adopt(l), t.adopt(r)
+	if m != nil {
+		value, found = m.value, true
+	}
+	return
+}
+
+// Join returns a tree of the items of left, the item of key, and the items of
+// right, whose keys must respectively be less than and greater than key, or else
+// it returns ErrNotSorted. Both trees must order their keys alike; the joined
+// tree orders them per left, and left and right are left empty, since their nodes
+// are reused. Join is O(|h(left) - h(right)|), as it only descends the taller tree.
+func Join[K, V any](left *AvlTree[K, V], key K, value V, right *AvlTree[K, V]) (*AvlTree[K, V], error) {
+	if last, _, ok := left.Max(); ok && left.cmp(last, key) >= 0 {
+		return nil, ErrNotSorted
+	}
+	if first, _, ok := right.Min(); ok && left.cmp(key, first) >= 0 {
+		return nil, ErrNotSorted
+	}
+
+	root := left.join(left.root, &Node[K, V]{key: key, value: value}, right.root)
+	left.root, left.nodeCount = nil, 0
+	right.root, right.nodeCount = nil, 0
+	return left.adopt(root), nil
+}
+
+// Union moves the items of other into the tree, keeping the tree's values of
+// keys in both, and leaves other empty. Both trees must order their keys alike.
+// It is O(m lg(n/m + 1)) for trees of m and n >= m items. The union of a tree
+// with itself leaves it unchanged.
+func (t *AvlTree[K, V]) Union(other *AvlTree[K, V]) {
+	if t == other {
+		return
+	}
+	t.root = t.union(t.root, other.root)
+	t.nodeCount = size(t.root)
+	other.root, other.nodeCount = nil, 0
+}
+
+// Intersection removes the items whose keys are not in other from the tree, and
+// leaves other empty, per Union. The intersection of a tree with itself leaves
+// it unchanged.
+func (t *AvlTree[K, V]) Intersection(other *AvlTree[K, V]) {
+	if t == other {
+		return
+	}
+	t.root = t.intersection(t.root, other.root)
+	t.nodeCount = size(t.root)
+	other.root, other.nodeCount = nil, 0
+}
+
+// Difference removes the items whose keys are in other from the tree, and
+// leaves other empty, per Union. The difference of a tree with itself empties it.
+func (t *AvlTree[K, V]) Difference(other *AvlTree[K, V]) {
+	if t == other {
+		t.root, t.nodeCount = nil, 0
+		return
+	}
+	t.root = t.difference(t.root, other.root)
+	t.nodeCount = size(t.root)
+	other.root, other.nodeCount = nil, 0
+}
+
+// FromSorted returns a tree of the keys, which must be in strictly ascending
+// order, or else it returns ErrNotSorted. The keys' values are zero, and may be
+// set with Put.
+func FromSorted[K cmp.Ordered, V any](keys []K) (*AvlTree[K, V], error) {
+	return FromSortedFunc[K, V](keys, cmp.Compare[K])
+}
+
+// FromSortedFunc returns a tree of the keys, which must be in strictly ascending
+// order per cmp, per FromSorted. It is O(n): each subtree is rooted at the middle
+// of its keys, such that the tree is balanced without rotations.
+func FromSortedFunc[K, V any](keys []K, cmp func(a, b K) int) (*AvlTree[K, V], error) {
+	for i := 1; i < len(keys); i++ {
+		if cmp(keys[i-1], keys[i]) >= 0 {
+			return nil, ErrNotSorted
+		}
+	}
+
+	t := NewTreeFunc[K, V](cmp)
+	t.root = build[K, V](keys)
+	t.nodeCount = len(keys)
+	return t, nil
+}
+
+// build returns the root of a balanced subtree of the sorted keys. Sibling
+// subtrees' sizes differ by at most one, so their heights do too.
+func build[K, V any](keys []K) *Node[K, V] {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	mid := len(keys) / 2
+	node := &Node[K, V]{
+		key:   keys[mid],
+		left:  build[K, V](keys[:mid]),
+		right: build[K, V](keys[mid+1:]),
+	}
+	setHeight(node)
+	setSize(node)
+	return node
+}
+
+// adopt returns a tree of the subtree at root, ordered as t.
+func (t *AvlTree[K, V]) adopt(root *Node[K, V]) *AvlTree[K, V] {
+	return &AvlTree[K, V]{
+		root:      root,
+		nodeCount: size(root),
+		cmp:       t.cmp,
+	}
+}
+
+// join returns the root of a balanced subtree of the subtree at l, the node m,
+// and the subtree at r, whose keys are ordered as such. It descends the taller
+// subtree's inner spine to a subtree as tall as the other, to which it attaches
+// m, and then balances the path back up as for an insertion.
+func (t *AvlTree[K, V]) join(l, m, r *Node[K, V]) *Node[K, V] {
+	switch {
+	case height(l) > height(r)+allowedImbalance:
+		l.right = t.join(l.right, m, r)
+		setHeight(l)
+		setSize(l)
+		t.balance(&l)
+		return l
+	case height(r) > height(l)+allowedImbalance:
+		r.left = t.join(l, m, r.left)
+		setHeight(r)
+		setSize(r)
+		t.balance(&r)
+		return r
+	}
+
+	m.left, m.right = l, r
+	setHeight(m)
+	setSize(m)
+	return m
+}
+
+// join2 returns the root of a balanced subtree of the subtrees at l and r,
+// whose keys are ordered as such, joined by l's maximum.
+func (t *AvlTree[K, V]) join2(l, r *Node[K, V]) *Node[K, V] {
+	if l == nil {
+		return r
+	}
+	rest, m := t.splitMax(l)
+	return t.join(rest, m, r)
+}
+
+// splitMax detaches the maximum node of the subtree at node, and returns the root
+// of the remaining subtree and the maximum.
+func (t *AvlTree[K, V]) splitMax(node *Node[K, V]) (rest, m *Node[K, V]) {
+	if node.right == nil {
+		return node.left, node
+	}
+	rest, m = t.splitMax(node.right)
+	return t.join(node.left, node, rest), m
+}
+
+// split divides the subtree at node into the subtrees at l and r of the keys
+// less than and greater than key, and returns the node of key, if any, as m.
+func (t *AvlTree[K, V]) split(node *Node[K, V], key K) (l, m, r *Node[K, V]) {
+	if node == nil {
+		return
+	}
+
+	left, right := node.left, node.right
+	c := t.cmp(key, node.key)
+	switch {
+	case c < 0:
+		l, m, r = t.split(left, key)
+		r = t.join(r, node, right)
+	case c > 0:
+		l, m, r = t.split(right, key)
+		l = t.join(left, node, l)
+	default:
+		l, m, r = left, node, right
+		m.left, m.right = nil, nil
+	}
+	return
+}
+
+// union returns the root of the union of the subtrees at a and b, keeping a's
+// nodes of keys in both.
+func (t *AvlTree[K, V]) union(a, b *Node[K, V]) *Node[K, V] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	bl, br := b.left, b.right
+	l, m, r := t.split(a, b.key)
+	if m == nil {
+		m = b
+	}
+	return t.join(t.union(l, bl), m, t.union(r, br))
+}
+
+// intersection returns the root of the nodes of a whose keys are in b.
+func (t *AvlTree[K, V]) intersection(a, b *Node[K, V]) *Node[K, V] {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	bl, br := b.left, b.right
+	l, m, r := t.split(a, b.key)
+	left, right := t.intersection(l, bl), t.intersection(r, br)
+	if m == nil {
+		return t.join2(left, right)
+	}
+	return t.join(left, m, right)
+}
+
+// difference returns the root of the nodes of a whose keys are not in b.
+func (t *AvlTree[K, V]) difference(a, b *Node[K, V]) *Node[K, V] {
+	if a == nil || b == nil {
+		return a
+	}
+
+	bl, br := b.left, b.right
+	l, _, r := t.split(a, b.key)
+	return t.join2(t.difference(l, bl), t.difference(r, br))
+}
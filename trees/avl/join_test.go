@@ -0,0 +1,263 @@
+package avl
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// treeKeys returns the tree's keys in ascending order.
+func treeKeys[V any](t *AvlTree[int, V]) (keys []int) {
+	for key := range t.Ascend(-1 << 62) {
+		keys = append(keys, key)
+	}
+	return
+}
+
+// randomTree returns a tree of n random keys below limit, whose values are
+// their keys times ten plus tag, and its sorted keys.
+func randomTree(r *rand.Rand, n, limit, tag int) (*AvlTree[int, int], []int) {
+	t := NewTree[int, int]()
+	for i := 0; i < n; i++ {
+		key := r.Intn(limit)
+		t.Put(key, key*10+tag)
+	}
+	return t, treeKeys(t)
+}
+
+// keySet returns the keys as a set.
+func keySet(keys []int) map[int]bool {
+	set := make(map[int]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// sortedKeys returns the set's keys in ascending order.
+func sortedKeys(set map[int]bool) (keys []int) {
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return
+}
+
+func TestSplitJoin(t *testing.T) {
+	Convey("Split and join tests", t, func() {
+		Convey("When a tree is split at a key it contains", func() {
+			t := NewTree[int, string]()
+			for i := 0; i < 100; i++ {
+				t.Put(i, "v")
+			}
+
+			left, right, value, found := t.Split(40)
+			So(found, ShouldBeTrue)
+			So(value, ShouldEqual, "v")
+			So(t.Len(), ShouldEqual, 0)
+			So(left.Len(), ShouldEqual, 40)
+			So(right.Len(), ShouldEqual, 59)
+			So(checkBalanced(left.root), ShouldBeTrue)
+			So(checkBalanced(right.root), ShouldBeTrue)
+
+			key, _, _ := left.Max()
+			So(key, ShouldEqual, 39)
+			key, _, _ = right.Min()
+			So(key, ShouldEqual, 41)
+		})
+
+		Convey("When a tree is split at a key it lacks", func() {
+			t, keys := randomTree(rand.New(rand.NewSource(1)), 500, 1000, 0)
+			left, right, _, found := t.Split(-1)
+			So(found, ShouldBeFalse)
+			So(left.Len(), ShouldEqual, 0)
+			So(treeKeys(right), ShouldResemble, keys)
+
+			left, right, _, found = right.Split(1000)
+			So(found, ShouldBeFalse)
+			So(treeKeys(left), ShouldResemble, keys)
+			So(right.Len(), ShouldEqual, 0)
+		})
+
+		Convey("When trees of different heights are joined", func() {
+			left, err := FromSorted[int, int]([]int{1, 2, 3})
+			So(err, ShouldBeNil)
+			var big []int
+			for i := 10; i < 1000; i++ {
+				big = append(big, i)
+			}
+			right, err := FromSorted[int, int](big)
+			So(err, ShouldBeNil)
+
+			joined, err := Join(left, 5, 50, right)
+			So(err, ShouldBeNil)
+			So(joined.Len(), ShouldEqual, 994)
+			So(checkBalanced(joined.root), ShouldBeTrue)
+			So(left.Len(), ShouldEqual, 0)
+			So(right.Len(), ShouldEqual, 0)
+			value, ok := joined.Get(5)
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, 50)
+
+			// The trees may also be joined the other way around, and be empty.
+			small, _ := FromSorted[int, int]([]int{2000})
+			joined, err = Join(joined, 1500, 0, small)
+			So(err, ShouldBeNil)
+			So(checkBalanced(joined.root), ShouldBeTrue)
+			joined, err = Join(NewTree[int, int](), -1, 0, joined)
+			So(err, ShouldBeNil)
+			So(joined.Len(), ShouldEqual, 997)
+			So(checkBalanced(joined.root), ShouldBeTrue)
+		})
+
+		Convey("When trees overlapping the key are joined", func() {
+			left, _ := FromSorted[int, int]([]int{1, 5})
+			right, _ := FromSorted[int, int]([]int{7, 9})
+			_, err := Join(left, 5, 0, right)
+			So(err, ShouldBeError, ErrNotSorted)
+			_, err = Join(left, 7, 0, right)
+			So(err, ShouldBeError, ErrNotSorted)
+			So(left.Len(), ShouldEqual, 2)
+		})
+
+		Convey("When random trees are split and rejoined", func() {
+			r := rand.New(rand.NewSource(2))
+			for i := 0; i < 20; i++ {
+				t, keys := randomTree(r, r.Intn(300), 500, 0)
+				key := r.Intn(500)
+				left, right, value, found := t.Split(key)
+				So(checkBalanced(left.root), ShouldBeTrue)
+				So(checkBalanced(right.root), ShouldBeTrue)
+				n := left.Len() + right.Len()
+				if found {
+					n++
+				}
+				So(n, ShouldEqual, len(keys))
+
+				if !found {
+					value = key * 10
+				}
+				joined, err := Join(left, key, value, right)
+				So(err, ShouldBeNil)
+				So(checkBalanced(joined.root), ShouldBeTrue)
+				So(treeKeys(joined), ShouldResemble, sortedKeys(keySet(append(keys, key))))
+			}
+		})
+	})
+}
+
+func TestSetOperations(t *testing.T) {
+	Convey("Set operation tests", t, func() {
+		r := rand.New(rand.NewSource(3))
+
+		Convey("When random trees are combined, the results match sets", func() {
+			for i := 0; i < 20; i++ {
+				n, m := r.Intn(400), r.Intn(40)
+				a, aKeys := randomTree(r, n, 500, 1)
+				b, bKeys := randomTree(r, m, 500, 2)
+				aSet, bSet := keySet(aKeys), keySet(bKeys)
+
+				union, intersection, difference := map[int]bool{}, map[int]bool{}, map[int]bool{}
+				for key := range aSet {
+					union[key] = true
+					if bSet[key] {
+						intersection[key] = true
+					} else {
+						difference[key] = true
+					}
+				}
+				for key := range bSet {
+					union[key] = true
+				}
+
+				u := NewTree[int, int]()
+				u.Union(a)
+				u.Union(b)
+				So(b.Len(), ShouldEqual, 0)
+				So(checkBalanced(u.root), ShouldBeTrue)
+				So(u.Len(), ShouldEqual, len(union))
+				So(treeKeys(u), ShouldResemble, sortedKeys(union))
+
+				a, _ = FromSorted[int, int](aKeys)
+				b, _ = FromSorted[int, int](bKeys)
+				a.Intersection(b)
+				So(checkBalanced(a.root), ShouldBeTrue)
+				So(a.Len(), ShouldEqual, len(intersection))
+				So(treeKeys(a), ShouldResemble, sortedKeys(intersection))
+
+				a, _ = FromSorted[int, int](aKeys)
+				b, _ = FromSorted[int, int](bKeys)
+				a.Difference(b)
+				So(checkBalanced(a.root), ShouldBeTrue)
+				So(a.Len(), ShouldEqual, len(difference))
+				So(treeKeys(a), ShouldResemble, sortedKeys(difference))
+			}
+		})
+
+		Convey("When keys are in both trees, the receiver's values are kept", func() {
+			a := NewTree[int, string]()
+			b := NewTree[int, string]()
+			for i := 0; i < 10; i++ {
+				a.Put(i, "a")
+				b.Put(i+5, "b")
+			}
+
+			a.Union(b)
+			So(a.Len(), ShouldEqual, 15)
+			value, _ := a.Get(7)
+			So(value, ShouldEqual, "a")
+			value, _ = a.Get(12)
+			So(value, ShouldEqual, "b")
+		})
+
+		Convey("When a tree is combined with itself", func() {
+			keys := []int{1, 2, 3, 5, 8, 13}
+			t, _ := FromSorted[int, int](keys)
+
+			t.Union(t)
+			So(t.Len(), ShouldEqual, len(keys))
+			So(treeKeys(t), ShouldResemble, keys)
+			So(checkBalanced(t.root), ShouldBeTrue)
+
+			t.Intersection(t)
+			So(t.Len(), ShouldEqual, len(keys))
+			So(treeKeys(t), ShouldResemble, keys)
+
+			t.Difference(t)
+			So(t.Len(), ShouldEqual, 0)
+			So(t.root, ShouldBeNil)
+		})
+	})
+}
+
+func TestFromSorted(t *testing.T) {
+	Convey("FromSorted tests", t, func() {
+		Convey("When sorted keys are built into a tree, it is balanced", func() {
+			for _, n := range []int{0, 1, 2, 3, 7, 8, 100, 1023, 1024} {
+				var keys []int
+				for i := 0; i < n; i++ {
+					keys = append(keys, i*2)
+				}
+				t, err := FromSorted[int, string](keys)
+				So(err, ShouldBeNil)
+				So(t.Len(), ShouldEqual, n)
+				So(checkBalanced(t.root), ShouldBeTrue)
+				So(treeKeys(t), ShouldResemble, keys)
+
+				// The tree is usable as any other.
+				t.Put(1, "one")
+				So(t.Len(), ShouldEqual, n+1)
+				So(checkBalanced(t.root), ShouldBeTrue)
+			}
+		})
+
+		Convey("When keys are unsorted or repeated, they are rejected", func() {
+			_, err := FromSorted[int, int]([]int{1, 3, 2})
+			So(err, ShouldBeError, ErrNotSorted)
+			_, err = FromSorted[int, int]([]int{1, 1})
+			So(err, ShouldBeError, ErrNotSorted)
+		})
+	})
+}
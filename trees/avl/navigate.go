@@ -0,0 +1,177 @@
+package avl
+
+import "iter"
+
+// Min returns the item of the smallest key, or false if the tree is empty.
+func (t *AvlTree[K, V]) Min() (key K, value V, ok bool) {
+	if t.root == nil {
+		return key, value, false
+	}
+	node := findMin(t.root)
+	return node.key, node.value, true
+}
+
+// Max returns the item of the largest key, or false if the tree is empty.
+func (t *AvlTree[K, V]) Max() (key K, value V, ok bool) {
+	if t.root == nil {
+		return key, value, false
+	}
+	node := findMax(t.root)
+	return node.key, node.value, true
+}
+
+func findMax[K, V any](node *Node[K, V]) *Node[K, V] {
+	if node.right == nil {
+		return node
+	}
+	return findMax(node.right)
+}
+
+// Floor returns the item of the largest key less than or equal to key, or false if there is none.
+func (t *AvlTree[K, V]) Floor(key K) (K, V, bool) {
+	return item(t.floor(key, true))
+}
+
+// Ceiling returns the item of the smallest key greater than or equal to key, or false if there is none.
+func (t *AvlTree[K, V]) Ceiling(key K) (K, V, bool) {
+	return item(t.ceiling(key, true))
+}
+
+// Predecessor returns the item of the largest key less than key, or false if there is none.
+func (t *AvlTree[K, V]) Predecessor(key K) (K, V, bool) {
+	return item(t.floor(key, false))
+}
+
+// Successor returns the item of the smallest key greater than key, or false if there is none.
+func (t *AvlTree[K, V]) Successor(key K) (K, V, bool) {
+	return item(t.ceiling(key, false))
+}
+
+// item returns the node's key and value, or false if it is nil.
+func item[K, V any](node *Node[K, V]) (key K, value V, ok bool) {
+	if node == nil {
+		return key, value, false
+	}
+	return node.key, node.value, true
+}
+
+// floor returns the node of the largest key less than key, or if inclusive,
+// less than or equal to it, or nil if there is none.
+func (t *AvlTree[K, V]) floor(key K, inclusive bool) (found *Node[K, V]) {
+	node := t.root
+	for node != nil {
+		c := t.cmp(node.key, key)
+		if c == 0 && inclusive {
+			return node
+		}
+		if c < 0 {
+			// The node is a candidate, but a larger one may be to its right.
+			found = node
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+	return
+}
+
+// ceiling returns the node of the smallest key greater than key, or if
+// inclusive, greater than or equal to it, or nil if there is none.
+func (t *AvlTree[K, V]) ceiling(key K, inclusive bool) (found *Node[K, V]) {
+	node := t.root
+	for node != nil {
+		c := t.cmp(node.key, key)
+		if c == 0 && inclusive {
+			return node
+		}
+		if c > 0 {
+			// The node is a candidate, but a smaller one may be to its left.
+			found = node
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return
+}
+
+// Ascend returns an iterator over the items whose keys are greater than or
+// equal to from, in ascending order. Iteration walks the tree with a stack of
+// at most its height, such that stopping early does not visit the remaining
+// items. The tree is read when iteration starts rather than when Ascend is
+// called, so each range over the iterator sees the tree as it then is. The
+// tree must not be modified during iteration.
+func (t *AvlTree[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		ascend(t.root, t.cmp, from)(yield)
+	}
+}
+
+// ascend returns an iterator over the items of the tree at root per Ascend.
+func ascend[K, V any](root *Node[K, V], cmp func(a, b K) int, from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		// The stack holds the nodes yet to be yielded whose left subtrees have been,
+		// or need not be, visited.
+		var stack []*Node[K, V]
+		for node := root; node != nil; {
+			if cmp(node.key, from) >= 0 {
+				stack = append(stack, node)
+				node = node.left
+			} else {
+				node = node.right
+			}
+		}
+
+		for len(stack) > 0 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(node.key, node.value) {
+				return
+			}
+			for next := node.right; next != nil; next = next.left {
+				stack = append(stack, next)
+			}
+		}
+	}
+}
+
+// Descend returns an iterator over the items whose keys are less than or
+// equal to from, in descending order, which otherwise behaves as Ascend,
+// including reading the tree only when iteration starts.
+func (t *AvlTree[K, V]) Descend(from K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var stack []*Node[K, V]
+		for node := t.root; node != nil; {
+			if t.cmp(node.key, from) <= 0 {
+				stack = append(stack, node)
+				node = node.right
+			} else {
+				node = node.left
+			}
+		}
+
+		for len(stack) > 0 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(node.key, node.value) {
+				return
+			}
+			for next := node.left; next != nil; next = next.right {
+				stack = append(stack, next)
+			}
+		}
+	}
+}
+
+// Range returns an iterator over the items whose keys k are such that
+// lo <= k <= hi, in ascending order, which otherwise behaves as Ascend,
+// including reading the tree only when iteration starts.
+func (t *AvlTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for key, value := range t.Ascend(lo) {
+			if t.cmp(key, hi) > 0 || !yield(key, value) {
+				return
+			}
+		}
+	}
+}
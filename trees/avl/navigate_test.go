@@ -0,0 +1,120 @@
+package avl
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// keysOf collects the keys of an iterator, stopping after limit of them if it is positive.
+func keysOf[K, V any](seq func(yield func(K, V) bool), limit int) (keys []K) {
+	for key := range seq {
+		keys = append(keys, key)
+		if len(keys) == limit {
+			break
+		}
+	}
+	return
+}
+
+func TestNavigation(t *testing.T) {
+	Convey("Navigation tests", t, func() {
+		t := NewTree[int, string]()
+		for _, v := range []int{50, 20, 80, 10, 30, 70, 90, 60} {
+			So(t.Insert(v, "v"), ShouldBeNil)
+		}
+
+		Convey("When Min and Max are called", func() {
+			key, _, ok := t.Min()
+			So(ok, ShouldBeTrue)
+			So(key, ShouldEqual, 10)
+			key, _, ok = t.Max()
+			So(ok, ShouldBeTrue)
+			So(key, ShouldEqual, 90)
+
+			empty := NewTree[int, string]()
+			_, _, ok = empty.Min()
+			So(ok, ShouldBeFalse)
+			_, _, ok = empty.Max()
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("When Floor, Ceiling, Predecessor and Successor are called", func() {
+			tcs := []struct {
+				key                                         int
+				floor, ceiling, predecessor, successor      int
+				hasFloor, hasCeiling, hasPred, hasSuccessor bool
+			}{
+				{key: 5, ceiling: 10, successor: 10, hasCeiling: true, hasSuccessor: true},
+				{key: 10, floor: 10, ceiling: 10, successor: 20, hasFloor: true, hasCeiling: true, hasSuccessor: true},
+				{key: 55, floor: 50, ceiling: 60, predecessor: 50, successor: 60, hasFloor: true, hasCeiling: true, hasPred: true, hasSuccessor: true},
+				{key: 60, floor: 60, ceiling: 60, predecessor: 50, successor: 70, hasFloor: true, hasCeiling: true, hasPred: true, hasSuccessor: true},
+				{key: 90, floor: 90, ceiling: 90, predecessor: 80, hasFloor: true, hasCeiling: true, hasPred: true},
+				{key: 95, floor: 90, predecessor: 90, hasFloor: true, hasPred: true},
+			}
+
+			for _, tc := range tcs {
+				key, _, ok := t.Floor(tc.key)
+				So(ok, ShouldEqual, tc.hasFloor)
+				So(key, ShouldEqual, tc.floor)
+				key, _, ok = t.Ceiling(tc.key)
+				So(ok, ShouldEqual, tc.hasCeiling)
+				So(key, ShouldEqual, tc.ceiling)
+				key, _, ok = t.Predecessor(tc.key)
+				So(ok, ShouldEqual, tc.hasPred)
+				So(key, ShouldEqual, tc.predecessor)
+				key, _, ok = t.Successor(tc.key)
+				So(ok, ShouldEqual, tc.hasSuccessor)
+				So(key, ShouldEqual, tc.successor)
+			}
+		})
+
+		Convey("When iterating in ascending order", func() {
+			So(keysOf(t.Ascend(0), 0), ShouldResemble, []int{10, 20, 30, 50, 60, 70, 80, 90})
+			So(keysOf(t.Ascend(55), 0), ShouldResemble, []int{60, 70, 80, 90})
+			So(keysOf(t.Ascend(30), 2), ShouldResemble, []int{30, 50})
+			So(keysOf(t.Ascend(91), 0), ShouldBeEmpty)
+		})
+
+		Convey("When iterating in descending order", func() {
+			So(keysOf(t.Descend(100), 0), ShouldResemble, []int{90, 80, 70, 60, 50, 30, 20, 10})
+			So(keysOf(t.Descend(55), 0), ShouldResemble, []int{50, 30, 20, 10})
+			So(keysOf(t.Descend(70), 3), ShouldResemble, []int{70, 60, 50})
+			So(keysOf(t.Descend(5), 0), ShouldBeEmpty)
+		})
+
+		Convey("When iterating over a range", func() {
+			So(keysOf(t.Range(20, 70), 0), ShouldResemble, []int{20, 30, 50, 60, 70})
+			So(keysOf(t.Range(21, 69), 0), ShouldResemble, []int{30, 50, 60})
+			So(keysOf(t.Range(20, 70), 1), ShouldResemble, []int{20})
+			So(keysOf(t.Range(70, 20), 0), ShouldBeEmpty)
+		})
+
+		Convey("When the tree is modified after iterators are created, they see the change", func() {
+			t := NewTree[int, string]()
+			ascending, descending, inRange := t.Ascend(0), t.Descend(100), t.Range(0, 100)
+			for _, v := range []int{30, 10, 20} {
+				t.Put(v, "")
+			}
+
+			So(keysOf(ascending, 0), ShouldResemble, []int{10, 20, 30})
+			So(keysOf(descending, 0), ShouldResemble, []int{30, 20, 10})
+			So(keysOf(inRange, 0), ShouldResemble, []int{10, 20, 30})
+		})
+
+		Convey("When iteration stops early, the rest of the tree is not visited", func() {
+			visited := 0
+			counting := NewTreeFunc[int, string](func(a, b int) int {
+				visited++
+				return a - b
+			})
+			for i := 0; i < 1024; i++ {
+				counting.Put(i, "")
+			}
+
+			visited = 0
+			So(keysOf(counting.Range(100, 1000), 3), ShouldResemble, []int{100, 101, 102})
+			So(visited, ShouldBeLessThan, 32)
+		})
+	})
+}
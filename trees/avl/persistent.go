@@ -0,0 +1,229 @@
+package avl
+
+import (
+	"cmp"
+	"iter"
+)
+
+// PersistentTree is an immutable version of an AVL tree. Insert, Put and Delete
+// return a new version, which copies only the path from the root to the change,
+// rebalancing the copies with the same rotations as AvlTree, and shares every
+// other subtree with the version it was derived from.
+//
+// Since no version is ever modified, every version remains valid once newer ones
+// are derived from it, and may be read from any number of goroutines without
+// locks, e.g. once it is published through an atomic.Pointer.
+type PersistentTree[K, V any] struct {
+	root      *Node[K, V]
+	nodeCount int
+	cmp       func(a, b K) int
+}
+
+// NewPersistentTree returns an empty persistent tree of naturally ordered keys.
+func NewPersistentTree[K cmp.Ordered, V any]() *PersistentTree[K, V] {
+	return NewPersistentTreeFunc[K, V](cmp.Compare[K])
+}
+
+// NewPersistentTreeFunc returns an empty persistent tree whose keys are ordered
+// by cmp, per NewTreeFunc.
+func NewPersistentTreeFunc[K, V any](cmp func(a, b K) int) *PersistentTree[K, V] {
+	return &PersistentTree[K, V]{
+		cmp: cmp,
+	}
+}
+
+// Snapshot returns the tree's version, which later versions derived from it do
+// not affect. Since versions are immutable, this is the tree itself.
+func (t *PersistentTree[K, V]) Snapshot() *PersistentTree[K, V] {
+	return t
+}
+
+// Len returns the number of items in the tree.
+func (t *PersistentTree[K, V]) Len() int {
+	return t.nodeCount
+}
+
+// Get returns the value stored under key, and whether it exists.
+func (t *PersistentTree[K, V]) Get(key K) (value V, ok bool) {
+	node := t.root
+	for node != nil {
+		c := t.cmp(key, node.key)
+		if c == 0 {
+			return node.value, true
+		}
+		if c < 0 {
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return value, false
+}
+
+// Ascend returns an iterator over the items whose keys are greater than or
+// equal to from, in ascending order, per AvlTree.Ascend. New versions may be
+// derived from the tree during iteration.
+func (t *PersistentTree[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return ascend(t.root, t.cmp, from)
+}
+
+// Insert returns a new version of the tree that includes a new item, or
+// ErrDuplicateItem if its key exists.
+func (t *PersistentTree[K, V]) Insert(key K, value V) (*PersistentTree[K, V], error) {
+	root, _, err := t.insert(t.root, key, value, false)
+	if err != nil {
+		return nil, err
+	}
+	return t.derive(root, t.nodeCount+1), nil
+}
+
+// Put returns a new version of the tree that includes a new item, or in which
+// the value of an existing key is replaced.
+func (t *PersistentTree[K, V]) Put(key K, value V) *PersistentTree[K, V] {
+	root, added, _ := t.insert(t.root, key, value, true)
+	if added {
+		return t.derive(root, t.nodeCount+1)
+	}
+	return t.derive(root, t.nodeCount)
+}
+
+// derive returns a version of the tree with the passed root.
+func (t *PersistentTree[K, V]) derive(root *Node[K, V], nodeCount int) *PersistentTree[K, V] {
+	return &PersistentTree[K, V]{
+		root:      root,
+		nodeCount: nodeCount,
+		cmp:       t.cmp,
+	}
+}
+
+// insert returns the root of a copy of the subtree at node that includes the
+// item, and whether the item was added rather than replaced.
+func (t *PersistentTree[K, V]) insert(node *Node[K, V], key K, value V, replace bool) (*Node[K, V], bool, error) {
+	// base case
+	if node == nil {
+		return &Node[K, V]{
+			key:    key,
+			value:  value,
+			height: 0,
+			size:   1,
+		}, true, nil
+	}
+
+	c := t.cmp(key, node.key)
+	if c == 0 {
+		if !replace {
+			return nil, false, ErrDuplicateItem
+		}
+		node = node.clone()
+		node.value = value
+		return node, false, nil
+	}
+
+	var child *Node[K, V]
+	var added bool
+	var err error
+	if c < 0 {
+		child, added, err = t.insert(node.left, key, value, replace)
+	} else {
+		child, added, err = t.insert(node.right, key, value, replace)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	node = node.clone()
+	if c < 0 {
+		node.left = child
+	} else {
+		node.right = child
+	}
+	return rebalanceCopy(node), added, nil
+}
+
+// Delete returns a new version of the tree that excludes key, or
+// ErrItemNotFound.
+func (t *PersistentTree[K, V]) Delete(key K) (*PersistentTree[K, V], error) {
+	root, err := t.delete(t.root, key)
+	if err != nil {
+		return nil, err
+	}
+	return t.derive(root, t.nodeCount-1), nil
+}
+
+// delete returns the root of a copy of the subtree at node that excludes key.
+func (t *PersistentTree[K, V]) delete(node *Node[K, V], key K) (*Node[K, V], error) {
+	if node == nil {
+		// item not found
+		return nil, ErrItemNotFound
+	}
+
+	c := t.cmp(key, node.key)
+	switch {
+	case c < 0:
+		left, err := t.delete(node.left, key)
+		if err != nil {
+			return nil, err
+		}
+		node = node.clone()
+		node.left = left
+	case c > 0:
+		right, err := t.delete(node.right, key)
+		if err != nil {
+			return nil, err
+		}
+		node = node.clone()
+		node.right = right
+	case node.left != nil && node.right != nil:
+		// As for AvlTree, the target is replaced by a copy of its min-right successor.
+		successor := findMin(node.right)
+		// err intentionally discarded because we know the item exists from the previous line
+		right, _ := t.delete(node.right, successor.key)
+		node = node.clone()
+		node.key, node.value = successor.key, successor.value
+		node.right = right
+	case node.left != nil:
+		// The node is merely in line to its child, which is shared as is.
+		return node.left, nil
+	default:
+		return node.right, nil
+	}
+
+	return rebalanceCopy(node), nil
+}
+
+// clone returns a shallow copy of the node, which shares its children.
+func (node *Node[K, V]) clone() *Node[K, V] {
+	c := *node
+	return &c
+}
+
+// rebalanceCopy updates a freshly copied node's height and size, and balances it
+// per AvlTree.balance. The rotations modify the children they rotate up, so
+// those are copied first, such that no node shared with another version changes.
+func rebalanceCopy[K, V any](node *Node[K, V]) *Node[K, V] {
+	setHeight(node)
+	setSize(node)
+
+	leftHeight := height(node.left)
+	rightHeight := height(node.right)
+
+	if leftHeight-rightHeight > allowedImbalance {
+		node.left = node.left.clone()
+		if outerLeftDeeper(node) {
+			rotateWithLeftChild(&node)
+		} else {
+			node.left.right = node.left.right.clone()
+			doubleRotateWithLeftChild(&node)
+		}
+	} else if rightHeight-leftHeight > allowedImbalance {
+		node.right = node.right.clone()
+		if outerRightDeeper(node) {
+			rotateWithRightChild(&node)
+		} else {
+			node.right.left = node.right.left.clone()
+			doubleRotateWithRightChild(&node)
+		}
+	}
+
+	return node
+}
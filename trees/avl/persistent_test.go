@@ -0,0 +1,156 @@
+package avl
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func persistentKeys[V any](t *PersistentTree[int, V]) (keys []int) {
+	for key := range t.Ascend(-1 << 62) {
+		keys = append(keys, key)
+	}
+	return
+}
+
+func TestPersistentTree(t *testing.T) {
+	Convey("Persistent tree tests", t, func() {
+		Convey("When a new version is derived, the old version is unaffected", func() {
+			t := NewPersistentTree[int, string]()
+			var err error
+			for i := 0; i < 8; i++ {
+				t, err = t.Insert(i, "v1")
+				So(err, ShouldBeNil)
+			}
+			snapshot := t.Snapshot()
+			So(snapshot, ShouldEqual, t)
+
+			t = t.Put(3, "v2")
+			t, err = t.Delete(5)
+			So(err, ShouldBeNil)
+			t, err = t.Insert(8, "v2")
+			So(err, ShouldBeNil)
+			_, err = t.Insert(8, "v3")
+			So(err, ShouldBeError, ErrDuplicateItem)
+			_, err = t.Delete(5)
+			So(err, ShouldBeError, ErrItemNotFound)
+
+			So(snapshot.Len(), ShouldEqual, 8)
+			So(persistentKeys(snapshot), ShouldResemble, []int{0, 1, 2, 3, 4, 5, 6, 7})
+			value, ok := snapshot.Get(3)
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, "v1")
+
+			So(t.Len(), ShouldEqual, 8)
+			So(persistentKeys(t), ShouldResemble, []int{0, 1, 2, 3, 4, 6, 7, 8})
+			value, ok = t.Get(3)
+			So(ok, ShouldBeTrue)
+			So(value, ShouldEqual, "v2")
+		})
+
+		Convey("When an item is inserted, only the path to it is copied", func() {
+			t := NewPersistentTree[int, int]()
+			for i := 0; i < 7; i++ {
+				t = t.Put(i, i)
+			}
+			/*
+				The resulting tree:
+
+						3
+					  /   \
+					1       5
+				   / \     / \
+				  0   2   4   6
+			*/
+			snapshot := t
+			t = t.Put(6, 60)
+
+			So(t.root, ShouldNotEqual, snapshot.root)
+			So(t.root.left, ShouldEqual, snapshot.root.left)
+			So(t.root.right, ShouldNotEqual, snapshot.root.right)
+			So(t.root.right.left, ShouldEqual, snapshot.root.right.left)
+		})
+
+		Convey("When random items are inserted and deleted, every version stays balanced", func() {
+			t := NewPersistentTree[int, int]()
+			r := rand.New(rand.NewSource(1))
+			var snapshots []*PersistentTree[int, int]
+			var expected [][]int
+			present := make(map[int]bool)
+
+			for i := 0; i < 2000; i++ {
+				key := r.Intn(300)
+				if r.Intn(3) == 0 {
+					next, err := t.Delete(key)
+					So(err == nil, ShouldEqual, present[key])
+					if err == nil {
+						t = next
+					}
+					delete(present, key)
+				} else {
+					t = t.Put(key, i)
+					present[key] = true
+				}
+
+				if i%100 == 0 {
+					var keys []int
+					for key := range present {
+						keys = append(keys, key)
+					}
+					sort.Ints(keys)
+					snapshots = append(snapshots, t.Snapshot())
+					expected = append(expected, keys)
+				}
+			}
+
+			for i, snapshot := range snapshots {
+				So(checkBalanced(snapshot.root), ShouldBeTrue)
+				So(snapshot.Len(), ShouldEqual, len(expected[i]))
+				So(persistentKeys(snapshot), ShouldResemble, expected[i])
+			}
+		})
+
+		Convey("When readers iterate snapshots while the tree is modified", func() {
+			t := NewPersistentTree[int, int]()
+			for i := 0; i < 1000; i++ {
+				t = t.Put(i, i)
+			}
+
+			var changed atomic.Bool
+			var wg sync.WaitGroup
+			for g := 0; g < 4; g++ {
+				snapshot := t.Snapshot()
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for k := 0; k < 5; k++ {
+						sum := 0
+						for _, value := range snapshot.Ascend(0) {
+							sum += value
+						}
+						if sum != 999*1000/2 {
+							changed.Store(true)
+						}
+					}
+				}()
+			}
+
+			for i := 0; i < 1000; i++ {
+				if i%2 == 0 {
+					next, err := t.Delete(i)
+					So(err, ShouldBeNil)
+					t = next
+				} else {
+					t = t.Put(i, -i)
+				}
+			}
+			wg.Wait()
+			So(changed.Load(), ShouldBeFalse)
+			So(t.Len(), ShouldEqual, 500)
+		})
+	})
+}
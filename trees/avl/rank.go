@@ -0,0 +1,58 @@
+package avl
+
+// Select returns the item of rank k, i.e. the k-th smallest key counting from
+// zero, or false if k is out of range. It is O(lg(n)), since each node stores
+// the size of its subtree.
+func (t *AvlTree[K, V]) Select(k int) (key K, value V, ok bool) {
+	if k < 0 || k >= t.nodeCount {
+		return key, value, false
+	}
+
+	node := t.root
+	for {
+		leftSize := size(node.left)
+		switch {
+		case k < leftSize:
+			node = node.left
+		case k > leftSize:
+			k -= leftSize + 1
+			node = node.right
+		default:
+			return node.key, node.value, true
+		}
+	}
+}
+
+// Rank returns the number of keys less than key, which is the rank that key
+// has, or would have if it were inserted.
+func (t *AvlTree[K, V]) Rank(key K) int {
+	return t.rank(key, false)
+}
+
+// CountRange returns the number of keys k such that lo <= k <= hi.
+func (t *AvlTree[K, V]) CountRange(lo, hi K) int {
+	if t.cmp(lo, hi) > 0 {
+		return 0
+	}
+	return t.rank(hi, true) - t.rank(lo, false)
+}
+
+// rank returns the number of keys less than key, or if inclusive, less than
+// or equal to it.
+func (t *AvlTree[K, V]) rank(key K, inclusive bool) (r int) {
+	node := t.root
+	for node != nil {
+		c := t.cmp(key, node.key)
+		if c < 0 || (c == 0 && !inclusive) {
+			node = node.left
+			continue
+		}
+		// The node and its left subtree are all counted.
+		r += size(node.left) + 1
+		if c == 0 {
+			return
+		}
+		node = node.right
+	}
+	return
+}
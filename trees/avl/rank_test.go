@@ -0,0 +1,75 @@
+package avl
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrderStatistics(t *testing.T) {
+	Convey("Order statistic tests", t, func() {
+		Convey("When keys are selected and ranked in a small tree", func() {
+			t := NewTree[int, string]()
+			for _, v := range []int{50, 20, 80, 10, 30, 70, 90} {
+				So(t.Insert(v, ""), ShouldBeNil)
+			}
+			So(t.root.size, ShouldEqual, 7)
+
+			for k, want := range []int{10, 20, 30, 50, 70, 80, 90} {
+				key, _, ok := t.Select(k)
+				So(ok, ShouldBeTrue)
+				So(key, ShouldEqual, want)
+				So(t.Rank(want), ShouldEqual, k)
+			}
+			_, _, ok := t.Select(-1)
+			So(ok, ShouldBeFalse)
+			_, _, ok = t.Select(7)
+			So(ok, ShouldBeFalse)
+
+			// Absent keys are ranked by where they would be inserted.
+			So(t.Rank(0), ShouldEqual, 0)
+			So(t.Rank(55), ShouldEqual, 4)
+			So(t.Rank(100), ShouldEqual, 7)
+
+			So(t.CountRange(20, 70), ShouldEqual, 4)
+			So(t.CountRange(21, 69), ShouldEqual, 2)
+			So(t.CountRange(0, 100), ShouldEqual, 7)
+			So(t.CountRange(70, 20), ShouldEqual, 0)
+			So(t.CountRange(51, 69), ShouldEqual, 0)
+		})
+
+		Convey("When random keys are inserted and deleted, sizes stay consistent", func() {
+			t := NewTree[int, int]()
+			r := rand.New(rand.NewSource(1))
+			present := make(map[int]bool)
+			for i := 0; i < 2000; i++ {
+				key := r.Intn(500)
+				if r.Intn(3) == 0 {
+					err := t.Delete(key)
+					So(err == nil, ShouldEqual, present[key])
+					delete(present, key)
+				} else {
+					t.Put(key, i)
+					present[key] = true
+				}
+			}
+			So(checkBalanced(t.root), ShouldBeTrue)
+			So(t.Len(), ShouldEqual, len(present))
+			So(size(t.root), ShouldEqual, len(present))
+
+			var keys []int
+			for key := range present {
+				keys = append(keys, key)
+			}
+			sort.Ints(keys)
+			for k, want := range keys {
+				key, _, ok := t.Select(k)
+				So(ok, ShouldBeTrue)
+				So(key, ShouldEqual, want)
+				So(t.Rank(want), ShouldEqual, k)
+			}
+		})
+	})
+}